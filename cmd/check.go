@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/spenserblack/termage/internal/utils"
+)
+
+// CheckFile decodes a file, and returns the error that decoding it failed
+// with, if any. Panics of decoders are returned as errors.
+func checkFile(filename string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Couldn't decode %q: %v", filename, r)
+		}
+	}()
+	_, _, _, err = utils.LoadImageMetadata(filename)
+	if err == utils.ErrNotAnimated {
+		err = nil
+	}
+	return
+}
+
+// CheckFiles finds the files with supported extensions in paths. Directories
+// are searched recursively, and files are kept even if their extension isn't
+// supported.
+func checkFiles(paths []string, extensions map[string]struct{}) ([]string, error) {
+	var filenames []string
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(filename string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			// NOTE Files that were given directly are checked, whatever their
+			//      extension
+			if _, ok := extensions[strings.ToLower(filepath.Ext(filename))]; ok || filename == path {
+				filenames = append(filenames, filename)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't search %q: %w", path, err)
+		}
+	}
+	return filenames, nil
+}
+
+// CheckAll decodes files in parallel, and returns the errors of the files in
+// the order of the files. Files that decoded have a nil error.
+func checkAll(filenames []string) []error {
+	errs := make([]error, len(filenames))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indices {
+				errs[index] = checkFile(filenames[index])
+			}
+		}()
+	}
+	for i := range filenames {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	return errs
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check <FILE | DIRECTORY>...",
+	Short: "Report images that fail to decode",
+	Long: heredoc.Doc(`
+		Decode images, and print the error of each image that fails to decode,
+		like corrupt or truncated images. Directories are searched
+		recursively for images.
+
+		The command fails if any image fails to decode, so it can be used to
+		audit directories in scripts.
+	`),
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filenames, err := checkFiles(args, Supported)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		out := cmd.OutOrStdout()
+		failed := 0
+		for _, err := range checkAll(filenames) {
+			if err != nil {
+				fmt.Fprintln(out, err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d files failed to decode", failed, len(filenames))
+		}
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(checkCmd)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCheck checks that the check command would report the images in a
+// directory that fail to decode, and fail.
+func TestCheck(t *testing.T) {
+	isolateConfig(t)
+	dir := t.TempDir()
+	pixel, err := os.ReadFile(getResource("internal", "utils", "pixel.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(dir, "nested")
+	if err := os.Mkdir(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		filepath.Join(dir, "good.jpg"):         pixel,
+		filepath.Join(nested, "truncated.jpg"): pixel[:len(pixel)/2],
+		filepath.Join(dir, "notes.txt"):        []byte("not an image"),
+	}
+	for filename, contents := range files {
+		if err := os.WriteFile(filename, contents, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	Supported = map[string]struct{}{".jpg": {}}
+	defer func() {
+		Supported = nil
+	}()
+	out := new(bytes.Buffer)
+	RootCmd.SetOut(out)
+	RootCmd.SetErr(new(bytes.Buffer))
+	RootCmd.SetArgs([]string{"check", dir})
+
+	_, err = RootCmd.ExecuteC()
+	if err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Errorf(`err = %v, want 1 of 2 files failing`, err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], "truncated.jpg") {
+		t.Errorf(`output = %q, want only the truncated image`, out)
+	}
+}
+
+// TestCheckValid checks that the check command would succeed for a file that
+// decodes.
+func TestCheckValid(t *testing.T) {
+	isolateConfig(t)
+	out := new(bytes.Buffer)
+	RootCmd.SetOut(out)
+	RootCmd.SetArgs([]string{"check", getResource("internal", "utils", "pixel.jpg")})
+
+	if _, err := RootCmd.ExecuteC(); err != nil {
+		t.Errorf(`err = %v, want nil`, err)
+	}
+	if actual := out.String(); actual != "" {
+		t.Errorf(`output = %q, want nothing`, actual)
+	}
+}
@@ -2,8 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/spf13/cobra"
+
+	"github.com/spenserblack/termage/internal/keys"
 )
 
 type controlMapping struct {
@@ -15,34 +19,57 @@ func (m controlMapping) String() string {
 	return fmt.Sprintf("%-10s%s", m.key, m.action)
 }
 
-var controls = []controlMapping{
-	controlMapping{"n", "Next image"},
-	controlMapping{"N", "Previous image"},
-	controlMapping{"z", "Increase zoom by 10 percentiles"},
-	controlMapping{"Z", "Decrease zoom by 10 percentiles"},
-	controlMapping{"f", "Fit to screen"},
-	controlMapping{"h", "Scroll left one pixel"},
-	controlMapping{"H", "Scroll left 10%"},
-	controlMapping{"j", "Scroll down one pixel"},
-	controlMapping{"J", "Scroll down 10%"},
-	controlMapping{"k", "Scroll up one pixel"},
-	controlMapping{"K", "Scroll up 10%"},
-	controlMapping{"l", "Scroll right one pixel"},
-	controlMapping{"L", "Scroll right 10%"},
-	controlMapping{"Esc", "Exit application"},
+// Controls gets the controls of each action from the effective key bindings.
+func controls(bindings keys.Bindings) []controlMapping {
+	mappings := make([]controlMapping, 0, len(keys.Registry))
+	for _, info := range keys.Registry {
+		boundKeys := bindings.Keys(info.Action)
+		names := make([]string, len(boundKeys))
+		for i, k := range boundKeys {
+			names[i] = k.String()
+		}
+		mappings = append(mappings, controlMapping{strings.Join(names, ", "), info.Description})
+	}
+	return mappings
 }
 
 var controlsCmd = &cobra.Command{
 	Use:   "controls",
 	Short: "Print controls",
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: heredoc.Doc(`
+		Print the controls of the image viewer.
+
+		Keys can be rebound in the config file by mapping action names to keys:
+
+		  [keys]
+		  next = ["n", "Right"]
+		  zoom-in = "Ctrl+Up"
+
+		Run "termage controls --actions" to list the action names.
+	`),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		out := cmd.OutOrStdout()
-		for _, control := range controls {
+		if listActions {
+			for _, info := range keys.Registry {
+				fmt.Fprintln(out, controlMapping{string(info.Action), info.Description})
+			}
+			return nil
+		}
+		bindings, err := loadBindings()
+		if err != nil {
+			return err
+		}
+		for _, control := range controls(bindings) {
 			fmt.Fprintln(out, control)
 		}
+		return nil
 	},
 }
 
+// ListActions lists the action names instead of their keys.
+var listActions bool
+
 func init() {
+	controlsCmd.Flags().BoolVar(&listActions, "actions", false, "list action names for the config file")
 	RootCmd.AddCommand(controlsCmd)
 }
@@ -3,6 +3,8 @@ package cmd
 import (
 	"bytes"
 	"testing"
+
+	"github.com/spenserblack/termage/internal/keys"
 )
 
 // TestControlString checks that a control has the proper string representation.
@@ -20,6 +22,7 @@ func TestControlString(t *testing.T) {
 
 // TestControlCommand makes sure that the correct subcommand is executed.
 func TestControlCommand(t *testing.T) {
+	isolateConfig(t)
 	out := new(bytes.Buffer)
 	RootCmd.SetOut(out)
 	RootCmd.SetArgs([]string{"controls"})
@@ -36,3 +39,21 @@ func TestControlCommand(t *testing.T) {
 		t.Fatalf(`command name = %q, want %q`, actual, want)
 	}
 }
+
+// TestControlsFromBindings checks that the controls would list the effective
+// keys of each action.
+func TestControlsFromBindings(t *testing.T) {
+	bindings := keys.DefaultBindings()
+	if err := bindings.Rebind(keys.Next, []string{"n", "Right"}); err != nil {
+		panic(err)
+	}
+
+	mappings := controls(bindings)
+
+	if actual, want := mappings[0].String(), "n, Right  Next image"; actual != want {
+		t.Errorf(`mappings[0] = %q, want %q`, actual, want)
+	}
+	if actual, want := len(mappings), len(keys.Registry); actual != want {
+		t.Errorf(`got %d mappings, want %d`, actual, want)
+	}
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/spenserblack/termage/internal/exif"
+	"github.com/spenserblack/termage/internal/utils"
+	"github.com/spenserblack/termage/pkg/gif"
+)
+
+// ImageInfo is the information that is printed about an image.
+type imageInfo struct {
+	File   string `json:"file"`
+	Format string `json:"format"`
+	// Width and Height are the dimensions that the image is stored with.
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// DisplayWidth and DisplayHeight are the dimensions of the image after
+	// it is rotated by its EXIF orientation.
+	DisplayWidth  int    `json:"displayWidth"`
+	DisplayHeight int    `json:"displayHeight"`
+	ColorModel    string `json:"colorModel"`
+	BitDepth      int    `json:"bitDepth"`
+	Frames        int    `json:"frames"`
+	// DurationMs is the length of one loop of an animation in milliseconds.
+	DurationMs *int64 `json:"durationMs,omitempty"`
+	// LoopCount is the number of times an animation repeats after it is first
+	// played. 0 repeats forever, and -1 plays the animation only once.
+	LoopCount *int      `json:"loopCount,omitempty"`
+	FileSize  int64     `json:"fileSize"`
+	EXIF      *exifInfo `json:"exif,omitempty"`
+
+	exif *exif.EXIF
+}
+
+// ExifInfo is the EXIF metadata that is printed about an image.
+type exifInfo struct {
+	Camera      string    `json:"camera,omitempty"`
+	Taken       string    `json:"taken,omitempty"`
+	Orientation int       `json:"orientation,omitempty"`
+	GPS         *exif.GPS `json:"gps,omitempty"`
+}
+
+// LoadInfo loads an image and collects its information.
+func loadInfo(filename string) (info imageInfo, err error) {
+	m, _, meta, err := utils.LoadImageMetadata(filename)
+	if err == utils.ErrNotAnimated {
+		err = nil
+	}
+	if err != nil {
+		return
+	}
+	// NOTE The image was rotated upright, so its size is the displayed size
+	size := m.Bounds().Size()
+	model := m.ColorModel()
+	info = imageInfo{
+		File:          filename,
+		Format:        meta.Format,
+		Width:         size.X,
+		Height:        size.Y,
+		DisplayWidth:  size.X,
+		DisplayHeight: size.Y,
+		Frames:        1,
+		FileSize:      meta.FileSize,
+		exif:          meta.EXIF,
+	}
+	if meta.EXIF != nil && meta.EXIF.Orientation.Swaps() {
+		info.Width, info.Height = size.Y, size.X
+	}
+	if g, ok := m.(*gif.Helper); ok {
+		// NOTE Only the first frame has the GIF's own palette
+		model = g.Frames[0].ColorModel()
+		info.Frames = len(g.Frames)
+		duration := g.Duration().Milliseconds()
+		info.DurationMs = &duration
+		loopCount := g.LoopCount()
+		info.LoopCount = &loopCount
+	}
+	info.ColorModel = utils.ColorModelName(model)
+	info.BitDepth = utils.BitDepth(model)
+	if x := meta.EXIF; x != nil {
+		info.EXIF = &exifInfo{
+			Camera:      x.Camera(),
+			Orientation: int(x.Orientation),
+			GPS:         x.GPS,
+		}
+		if !x.Taken.IsZero() {
+			info.EXIF.Taken = x.Taken.Format("2006-01-02T15:04:05")
+		}
+	}
+	return
+}
+
+// WriteTo writes the information for people to read.
+func (info imageInfo) writeTo(out io.Writer) {
+	field := func(name string, value interface{}) {
+		fmt.Fprintf(out, "%-13s%v\n", name, value)
+	}
+	field("Format", info.Format)
+	field("Dimensions", fmt.Sprintf("%dx%d", info.Width, info.Height))
+	if info.DisplayWidth != info.Width || info.DisplayHeight != info.Height {
+		field("Displayed as", fmt.Sprintf("%dx%d", info.DisplayWidth, info.DisplayHeight))
+	}
+	field("Color model", info.ColorModel)
+	field("Bit depth", info.BitDepth)
+	field("Frames", info.Frames)
+	if info.DurationMs != nil {
+		field("Duration", fmt.Sprintf("%dms", *info.DurationMs))
+	}
+	if info.LoopCount != nil {
+		switch loops := *info.LoopCount; loops {
+		case 0:
+			field("Loop count", "forever")
+		case -1:
+			field("Loop count", "none")
+		default:
+			field("Loop count", loops)
+		}
+	}
+	fileSize := utils.FormatFileSize(info.FileSize)
+	if info.FileSize >= 1024 {
+		fileSize = fmt.Sprintf("%s (%d bytes)", fileSize, info.FileSize)
+	}
+	field("File size", fileSize)
+	if info.exif != nil {
+		for _, f := range info.exif.Fields() {
+			field(f.Name, f.Value)
+		}
+	}
+}
+
+var infoCmd = &cobra.Command{
+	Use:   "info FILE...",
+	Short: "Print image information",
+	Long: heredoc.Doc(`
+		Print the format, dimensions, color model, bit depth and file size of
+		images, the frames, duration and loop count of animations, and the
+		EXIF metadata of photos.
+
+		With --json, a JSON array with an object for each image is printed.
+		The width and height are the dimensions that the image is stored
+		with, and displayWidth and displayHeight are its dimensions after it
+		is rotated by its EXIF orientation. The loop count of animations is 0
+		if they repeat forever, and -1 if they play only once.
+	`),
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		infos := make([]imageInfo, 0, len(args))
+		for _, filename := range args {
+			info, err := loadInfo(filename)
+			if err != nil {
+				return err
+			}
+			infos = append(infos, info)
+		}
+		if infoJSON {
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(infos)
+		}
+		for i, info := range infos {
+			if len(infos) > 1 {
+				if i > 0 {
+					fmt.Fprintln(out)
+				}
+				fmt.Fprintf(out, "%s:\n", info.File)
+			}
+			info.writeTo(out)
+		}
+		return nil
+	},
+}
+
+// InfoJSON prints the information as JSON.
+var infoJSON bool
+
+func init() {
+	infoCmd.Flags().BoolVar(&infoJSON, "json", false, "print the information as JSON")
+	RootCmd.AddCommand(infoCmd)
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	_ "image/gif" // Register GIFs for tests
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// GetResource gets the path of a test resource.
+func getResource(path ...string) string {
+	return filepath.Join(append([]string{"..", "_resources", "tests"}, path...)...)
+}
+
+// TestInfo checks that the info command would print the properties of an
+// image.
+func TestInfo(t *testing.T) {
+	isolateConfig(t)
+	out := new(bytes.Buffer)
+	RootCmd.SetOut(out)
+	RootCmd.SetArgs([]string{"info", getResource("internal", "utils", "pixel.jpg")})
+
+	if _, err := RootCmd.ExecuteC(); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	want := "Format       jpeg\n" +
+		"Dimensions   1x1\n" +
+		"Color model  YCbCr\n" +
+		"Bit depth    8\n" +
+		"Frames       1\n" +
+		"File size    518 B\n"
+	if actual := out.String(); actual != want {
+		t.Errorf(`output = %q, want %q`, actual, want)
+	}
+}
+
+// TestInfoJSON checks that the info command would print the properties of
+// animations as JSON.
+func TestInfoJSON(t *testing.T) {
+	isolateConfig(t)
+	out := new(bytes.Buffer)
+	RootCmd.SetOut(out)
+	RootCmd.SetArgs([]string{"info", "--json", getResource("pkg", "gif", "spinning-2x2-3loop.gif")})
+	defer func() {
+		infoJSON = false
+	}()
+
+	if _, err := RootCmd.ExecuteC(); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	var infos []imageInfo
+	if err := json.Unmarshal(out.Bytes(), &infos); err != nil {
+		t.Fatalf(`Couldn't parse output %q: %v`, out, err)
+	}
+	if len(infos) != 1 {
+		t.Fatalf(`got %d images, want 1`, len(infos))
+	}
+	info := infos[0]
+	if info.Format != "gif" || info.Width != 2 || info.Height != 2 || info.Frames != 4 {
+		t.Errorf(`info = %+v, want a 2x2 gif with 4 frames`, info)
+	}
+	if info.LoopCount == nil || *info.LoopCount != 3 {
+		t.Errorf(`loop count = %v, want 3`, info.LoopCount)
+	}
+	if info.DurationMs == nil || *info.DurationMs <= 0 {
+		t.Errorf(`duration = %v, want a positive duration`, info.DurationMs)
+	}
+}
+
+// TestInfoOrientation checks that the info command would print the stored
+// dimensions of a rotated photo, and the dimensions it is displayed with.
+func TestInfoOrientation(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, image.NewGray(image.Rect(0, 0, 4, 2)), nil); err != nil {
+		t.Fatal(err)
+	}
+	// NOTE A little-endian TIFF structure with an orientation of 6, which
+	//      rotates the photo by 90 degrees
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x01\x00\x12\x01\x03\x00\x01\x00\x00\x00\x06\x00\x00\x00\x00\x00\x00\x00")
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	data := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	data = binary.BigEndian.AppendUint16(data, uint16(len(segment)+2))
+	data = append(data, segment...)
+	data = append(data, encoded.Bytes()[2:]...)
+	filename := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(filename, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := loadInfo(filename)
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if info.Width != 4 || info.Height != 2 {
+		t.Errorf(`dimensions = %dx%d, want 4x2`, info.Width, info.Height)
+	}
+	if info.DisplayWidth != 2 || info.DisplayHeight != 4 {
+		t.Errorf(`displayed dimensions = %dx%d, want 2x4`, info.DisplayWidth, info.DisplayHeight)
+	}
+}
+
+// TestInfoMissingFile checks that the info command would fail for a file that
+// doesn't exist.
+func TestInfoMissingFile(t *testing.T) {
+	isolateConfig(t)
+	RootCmd.SetOut(new(bytes.Buffer))
+	RootCmd.SetErr(new(bytes.Buffer))
+	RootCmd.SetArgs([]string{"info", filepath.Join(t.TempDir(), "missing.jpg")})
+
+	if _, err := RootCmd.ExecuteC(); err == nil {
+		t.Errorf(`err = nil`)
+	}
+}
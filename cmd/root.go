@@ -2,12 +2,25 @@ package cmd
 
 import (
 	"fmt"
+	"image/color"
 	"os"
+	"regexp"
+	"time"
 
 	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/gdamore/tcell/v2"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	internal "github.com/spenserblack/termage/internal/cmd"
+	"github.com/spenserblack/termage/internal/config"
+	"github.com/spenserblack/termage/internal/conversion"
+	"github.com/spenserblack/termage/internal/draw"
+	"github.com/spenserblack/termage/internal/files"
+	"github.com/spenserblack/termage/internal/keys"
+	"github.com/spenserblack/termage/internal/transform"
+	"github.com/spenserblack/termage/internal/view"
+	"github.com/spenserblack/termage/pkg/render"
 )
 
 // Vars for mocking.
@@ -35,16 +48,232 @@ var (
 			If a single image file is passed, you will browse all images in the same
 			directory as that image.
 			If multiple files are passed, then you will browse specifically those files.
+			When quitting, the marked files are printed, so that they can be used in
+			pipelines.
 		`),
 		Args: cobra.MinimumNArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			ImageFiles = args
-			mainFunc(ImageFiles, Supported)
+			options, err := viewerOptions(cmd.Flags())
+			if err != nil {
+				return err
+			}
+			// NOTE The flags are valid, so errors of the viewer shouldn't
+			//      print the usage
+			cmd.SilenceUsage = true
+			options.Output, options.Warnings = cmd.OutOrStdout(), cmd.ErrOrStderr()
+			return mainFunc(ImageFiles, Supported, options)
 		},
 		Version: "0.6.2",
 	}
 )
 
+// Flags for the root command.
+var (
+	includeGlobs   []string
+	excludeGlobs   []string
+	includeRegexps []string
+	excludeRegexps []string
+	minSize        string
+	maxSize        string
+	rotate         string
+	flipHorizontal bool
+	flipVertical   bool
+	skipBroken     bool
+	markBroken     bool
+	slideshow      time.Duration
+	slideShuffle   bool
+	slideStop      bool
+	shuffle        bool
+	seed           int64
+	print0         bool
+	printTags      []string
+)
+
+// ConfigPath is the path of the config file, if it was set by the user.
+var configPath string
+
+// FlagConfig contains the config options that were set with flags.
+var flagConfig config.Config
+
+// LoadConfig loads the config file, and overrides it with environment
+// variables.
+func loadConfig() (c config.Config, err error) {
+	if configPath != "" {
+		c, err = config.Load(configPath)
+	} else {
+		c, err = config.LoadDefault()
+	}
+	if err != nil {
+		return
+	}
+	err = c.LoadEnv()
+	return
+}
+
+// ApplyConfigFlags overrides the config with the flags that the user set.
+func applyConfigFlags(c *config.Config, flags *pflag.FlagSet) {
+	for name, field := range map[string]*string{
+		"mode":       &c.Mode,
+		"colors":     &c.Colors,
+		"background": &c.Background,
+		"sort":       &c.Sort,
+		"zoom":       &c.Zoom,
+		"filter":     &c.Filter,
+	} {
+		if flags.Changed(name) {
+			*field = flags.Lookup(name).Value.String()
+		}
+	}
+	if flags.Changed("scroll-step") {
+		c.ScrollStep = flagConfig.ScrollStep
+	}
+}
+
+// LoadBindings loads the effective key bindings.
+func loadBindings() (keys.Bindings, error) {
+	c, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return c.Bindings()
+}
+
+// ViewerOptions converts the config and the root command's flags to options
+// for the viewer.
+func viewerOptions(flags *pflag.FlagSet) (options internal.Options, err error) {
+	c, err := loadConfig()
+	if err != nil {
+		return
+	}
+	applyConfigFlags(&c, flags)
+	if options.Bindings, err = c.Bindings(); err != nil {
+		return
+	}
+	if options.Converter.Mode, err = conversion.ParseMode(c.Mode); err != nil {
+		return
+	}
+	if options.Style.Palette, err = draw.ParsePalette(c.Colors); err != nil {
+		return
+	}
+	if c.Background != "" {
+		background := tcell.GetColor(c.Background)
+		if background == tcell.ColorDefault {
+			return options, fmt.Errorf("Unknown background color %q", c.Background)
+		}
+		options.Style.Background = background
+		r, g, b := background.RGB()
+		options.Converter.Background = color.RGBA{uint8(r), uint8(g), uint8(b), 0xFF}
+	}
+	if options.Sort, err = files.ParseSortOrder(c.Sort); err != nil {
+		return
+	}
+	if options.ZoomMode, options.Zoom, err = view.ParseZoom(c.Zoom); err != nil {
+		return
+	}
+	if options.Resampling, err = render.ParseFilter(c.Filter); err != nil {
+		return
+	}
+	if c.ScrollStep <= 0 {
+		return options, fmt.Errorf("Scroll step must be positive, got %d", c.ScrollStep)
+	}
+	options.ScrollStep = c.ScrollStep
+	options.SkipBroken, options.MarkBroken = skipBroken, markBroken
+	if slideshow < 0 {
+		return options, fmt.Errorf("Slideshow interval must be positive, got %v", slideshow)
+	}
+	options.Slideshow = slideshow
+	options.SlideshowShuffle, options.SlideshowStopAtEnd = slideShuffle, slideStop
+	options.Shuffle, options.Seed = shuffle, seed
+	if !flags.Changed("seed") {
+		options.Seed = time.Now().UnixNano()
+	}
+	options.PrintNull, options.PrintTags = print0, printTags
+	if options.Transform, err = viewTransform(); err != nil {
+		return
+	}
+
+	filter := &options.Filter
+	filter.Include = includeGlobs
+	filter.Exclude = excludeGlobs
+	if filter.IncludeRegexp, err = compileRegexps(includeRegexps); err != nil {
+		return
+	}
+	if filter.ExcludeRegexp, err = compileRegexps(excludeRegexps); err != nil {
+		return
+	}
+	if minSize != "" {
+		if filter.MinSize, err = files.ParseSize(minSize); err != nil {
+			return
+		}
+	}
+	if maxSize != "" {
+		if filter.MaxSize, err = files.ParseSize(maxSize); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ViewTransform gets the transform of the view from the flags. Images are
+// flipped, and then rotated.
+func viewTransform() (t transform.Transform, err error) {
+	if flipHorizontal {
+		t = t.FlipHorizontal()
+	}
+	if flipVertical {
+		t = t.FlipVertical()
+	}
+	rotation, err := transform.ParseRotation(rotate)
+	if err != nil {
+		return
+	}
+	return t.Rotate(rotation), nil
+}
+
+// CompileRegexps compiles all regular expressions in a slice.
+func compileRegexps(exprs []string) ([]*regexp.Regexp, error) {
+	regexps := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid regular expression %q: %w", expr, err)
+		}
+		regexps = append(regexps, re)
+	}
+	return regexps, nil
+}
+
+func init() {
+	RootCmd.PersistentFlags().StringVar(&configPath, "config", "", "load config from `path` instead of $XDG_CONFIG_HOME/termage/config.toml")
+	flags := RootCmd.Flags()
+	flags.StringArrayVar(&includeGlobs, "include", nil, "only browse files matching a glob `pattern`")
+	flags.StringArrayVar(&excludeGlobs, "exclude", nil, "skip files matching a glob `pattern`")
+	flags.StringArrayVar(&includeRegexps, "include-regex", nil, "only browse files matching a regular `expression`")
+	flags.StringArrayVar(&excludeRegexps, "exclude-regex", nil, "skip files matching a regular `expression`")
+	flags.StringVar(&minSize, "min-size", "", "skip images smaller than `WIDTHxHEIGHT`")
+	flags.StringVar(&maxSize, "max-size", "", "skip images larger than `WIDTHxHEIGHT`")
+	flags.StringVar(&flagConfig.Mode, "mode", "alpha", "render `mode`: alpha, block or ascii")
+	flags.StringVar(&flagConfig.Colors, "colors", "truecolor", "`colors` to draw with: truecolor, 256, 16 or none")
+	flags.StringVar(&flagConfig.Background, "background", "", "background `color` of images, like black or \"#1d1f21\"")
+	flags.StringVar(&flagConfig.Sort, "sort", "none", "sort `order`: none, name, natural, mtime or size")
+	flags.StringVar(&flagConfig.Zoom, "zoom", "fit", "initial `zoom`: fit, fit-width, fit-height, 1:1 or a percentage")
+	flags.StringVar(&flagConfig.Filter, "filter", "linear", "resampling `filter` when zooming: nearest, box, linear, catmull-rom or lanczos")
+	flags.StringVar(&rotate, "rotate", "0", "rotate images clockwise by `degrees`: 90, 180 or 270")
+	flags.BoolVar(&flipHorizontal, "flip-horizontal", false, "flip images horizontally")
+	flags.BoolVar(&flipVertical, "flip-vertical", false, "flip images vertically")
+	flags.BoolVar(&skipBroken, "skip-broken", false, "skip files that fail to load")
+	flags.BoolVar(&markBroken, "mark-broken", false, "mark files that failed to load in the title and in searches")
+	flags.DurationVar(&slideshow, "slideshow", 0, "start a slideshow that shows each image for a `duration`, like 10s")
+	flags.BoolVar(&slideShuffle, "slideshow-shuffle", false, "show the slideshow in a random order")
+	flags.BoolVar(&slideStop, "slideshow-stop-at-end", false, "stop the slideshow once each image was shown")
+	flags.BoolVar(&shuffle, "shuffle", false, "browse the images in a random order")
+	flags.Int64Var(&seed, "seed", 0, "`seed` of the random order and random images, to repeat them (random by default)")
+	flags.BoolVar(&print0, "print0", false, "separate the marked files that are printed when quitting with NUL instead of newlines")
+	flags.StringArrayVar(&printTags, "print-tag", nil, "print the files with a `tag` instead of the marked files when quitting")
+	flags.IntVar(&flagConfig.ScrollStep, "scroll-step", 10, "`percentage` of the image to scroll with H, J, K and L")
+}
+
 // Execute runs this project's CLI.
 func Execute() {
 	if err := RootCmd.Execute(); err != nil {
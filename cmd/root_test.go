@@ -2,14 +2,33 @@ package cmd
 
 import (
 	"bytes"
+	"image"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/gdamore/tcell/v2"
+
 	internal "github.com/spenserblack/termage/internal/cmd"
+	"github.com/spenserblack/termage/internal/config"
+	"github.com/spenserblack/termage/internal/conversion"
+	"github.com/spenserblack/termage/internal/draw"
+	"github.com/spenserblack/termage/internal/transform"
+	"github.com/spenserblack/termage/pkg/render"
 )
 
+// IsolateConfig keeps the user's config file and environment variables from
+// changing the options of a test.
+func isolateConfig(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	for _, name := range []string{"MODE", "COLORS", "BACKGROUND", "SORT", "ZOOM", "FILTER", "SCROLL_STEP"} {
+		t.Setenv(config.EnvPrefix+name, "")
+	}
+}
+
 // Test1ArgMinimum checks that the root command requires at least 1 argument.
 func Test1ArgMinimum(t *testing.T) {
+	isolateConfig(t)
 	outErr := new(bytes.Buffer)
 	RootCmd.SetErr(outErr)
 	RootCmd.SetArgs([]string{})
@@ -24,8 +43,9 @@ func Test1ArgMinimum(t *testing.T) {
 // TestImageFiles checks that the root command will collect the positional
 // arguments as image filepaths.
 func TestImageFiles(t *testing.T) {
+	isolateConfig(t)
 	args := []string{"path/to/image1.ext", "path/to/image2.example"}
-	mainFunc = func([]string, map[string]struct{}) {}
+	mainFunc = func([]string, map[string]struct{}, internal.Options) error { return nil }
 	defer func() {
 		mainFunc = internal.Root
 	}()
@@ -47,6 +67,7 @@ func TestImageFiles(t *testing.T) {
 
 // TestExecute checks that root.Execute would exit if an error is returned.
 func TestExecute(t *testing.T) {
+	isolateConfig(t)
 	// execute := RootCmd.Execute
 	exited := false
 	// RootCmd.Execute = func(*cobra.Command) error {
@@ -70,3 +91,203 @@ func TestExecute(t *testing.T) {
 		t.Errorf(`Would not have exited on RootCmd execution error`)
 	}
 }
+
+// TestFilterFlags checks that the filter flags would be passed to the viewer.
+func TestFilterFlags(t *testing.T) {
+	isolateConfig(t)
+	var options internal.Options
+	mainFunc = func(_ []string, _ map[string]struct{}, o internal.Options) error {
+		options = o
+		return nil
+	}
+	defer func() {
+		mainFunc = internal.Root
+		includeGlobs, excludeRegexps, minSize = nil, nil, ""
+	}()
+
+	RootCmd.SetArgs([]string{
+		"--include", "*_diff.png",
+		"--exclude-regex", "^old",
+		"--min-size", "640x",
+		"dir",
+	})
+
+	if _, err := RootCmd.ExecuteC(); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	filter := options.Filter
+	if len(filter.Include) != 1 || filter.Include[0] != "*_diff.png" {
+		t.Errorf(`Include = %v, want [*_diff.png]`, filter.Include)
+	}
+	if len(filter.ExcludeRegexp) != 1 || filter.ExcludeRegexp[0].String() != "^old" {
+		t.Errorf(`ExcludeRegexp = %v, want [^old]`, filter.ExcludeRegexp)
+	}
+	if want := (image.Point{640, 0}); filter.MinSize != want {
+		t.Errorf(`MinSize = %v, want %v`, filter.MinSize, want)
+	}
+}
+
+// TestTransformFlags checks that images would be flipped and then rotated by
+// the flags.
+func TestTransformFlags(t *testing.T) {
+	isolateConfig(t)
+	var options internal.Options
+	mainFunc = func(_ []string, _ map[string]struct{}, o internal.Options) error {
+		options = o
+		return nil
+	}
+	defer func() {
+		mainFunc = internal.Root
+		rotate, flipHorizontal, flipVertical = "0", false, false
+	}()
+
+	RootCmd.SetArgs([]string{"--rotate", "90", "--flip-vertical", "dir"})
+
+	if _, err := RootCmd.ExecuteC(); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	if want := (transform.Transform{Rotation: 270, Mirror: true}); options.Transform != want {
+		t.Errorf(`Transform = %+v, want %+v`, options.Transform, want)
+	}
+}
+
+// TestFilterFlag checks that the resampling filter would be set by the flag,
+// and that unknown filters would be an error.
+func TestFilterFlag(t *testing.T) {
+	isolateConfig(t)
+	var options internal.Options
+	mainFunc = func(_ []string, _ map[string]struct{}, o internal.Options) error {
+		options = o
+		return nil
+	}
+	defer func() {
+		mainFunc = internal.Root
+		RootCmd.Flags().Set("filter", "linear")
+		RootCmd.Flags().Lookup("filter").Changed = false
+	}()
+
+	RootCmd.SetArgs([]string{"--filter", "nearest", "dir"})
+	if _, err := RootCmd.ExecuteC(); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if options.Resampling != render.Nearest {
+		t.Errorf(`Resampling = %v, want %v`, options.Resampling, render.Nearest)
+	}
+
+	RootCmd.SetErr(new(bytes.Buffer))
+	RootCmd.SetArgs([]string{"--filter", "bicubic", "dir"})
+	if _, err := RootCmd.ExecuteC(); err == nil {
+		t.Errorf(`err = nil`)
+	}
+}
+
+// TestSeedFlag checks that the seed would be set by the flag, so that the
+// shuffled order can be repeated.
+func TestSeedFlag(t *testing.T) {
+	isolateConfig(t)
+	var options internal.Options
+	mainFunc = func(_ []string, _ map[string]struct{}, o internal.Options) error {
+		options = o
+		return nil
+	}
+	defer func() {
+		mainFunc = internal.Root
+		shuffle, seed = false, 0
+		RootCmd.Flags().Lookup("seed").Changed = false
+	}()
+
+	RootCmd.SetArgs([]string{"--shuffle", "--seed", "42", "dir"})
+	if _, err := RootCmd.ExecuteC(); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if !options.Shuffle || options.Seed != 42 {
+		t.Errorf(`Shuffle, Seed = %v, %d, want true, 42`, options.Shuffle, options.Seed)
+	}
+}
+
+// TestPrintFlags checks that the marked files would be printed to the output
+// of the command, with the separator and tags of the flags.
+func TestPrintFlags(t *testing.T) {
+	isolateConfig(t)
+	var options internal.Options
+	mainFunc = func(_ []string, _ map[string]struct{}, o internal.Options) error {
+		options = o
+		return nil
+	}
+	defer func() {
+		mainFunc = internal.Root
+		print0, printTags = false, nil
+	}()
+	out := new(bytes.Buffer)
+	RootCmd.SetOut(out)
+
+	RootCmd.SetArgs([]string{"--print0", "--print-tag", "keep", "dir"})
+	if _, err := RootCmd.ExecuteC(); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if options.Output != out {
+		t.Errorf(`Output = %v, want the output of the command`, options.Output)
+	}
+	if !options.PrintNull || len(options.PrintTags) != 1 || options.PrintTags[0] != "keep" {
+		t.Errorf(`PrintNull, PrintTags = %v, %v, want true, [keep]`, options.PrintNull, options.PrintTags)
+	}
+}
+
+// TestBadRegexFlag checks that an invalid regular expression is an error.
+func TestBadRegexFlag(t *testing.T) {
+	isolateConfig(t)
+	mainFunc = func([]string, map[string]struct{}, internal.Options) error { return nil }
+	defer func() {
+		mainFunc = internal.Root
+		includeRegexps = nil
+	}()
+
+	RootCmd.SetErr(new(bytes.Buffer))
+	RootCmd.SetArgs([]string{"--include-regex", "(", "dir"})
+
+	if _, err := RootCmd.ExecuteC(); err == nil {
+		t.Errorf(`err = nil`)
+	}
+}
+
+// TestConfigPrecedence checks that flags would override environment variables,
+// which would override the config file.
+func TestConfigPrecedence(t *testing.T) {
+	isolateConfig(t)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	contents := "mode = \"block\"\ncolors = \"16\"\nbackground = \"black\"\n"
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		panic(err)
+	}
+	t.Setenv("TERMAGE_COLORS", "256")
+	t.Setenv("TERMAGE_MODE", "alpha")
+	var options internal.Options
+	mainFunc = func(_ []string, _ map[string]struct{}, o internal.Options) error {
+		options = o
+		return nil
+	}
+	defer func() {
+		mainFunc = internal.Root
+		configPath = ""
+		RootCmd.Flags().Set("mode", "alpha")
+		RootCmd.Flags().Lookup("mode").Changed = false
+	}()
+
+	RootCmd.SetArgs([]string{"--config", path, "--mode", "ascii", "dir"})
+
+	if _, err := RootCmd.ExecuteC(); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	if actual := options.Converter.Mode; actual != conversion.ASCIIMode {
+		t.Errorf(`Mode = %v, want flag's %v`, actual, conversion.ASCIIMode)
+	}
+	if actual := options.Style.Palette; actual != draw.Palette256 {
+		t.Errorf(`Palette = %v, want env's %v`, actual, draw.Palette256)
+	}
+	if actual := options.Style.Background; actual != tcell.ColorBlack {
+		t.Errorf(`Background = %v, want config's %v`, actual, tcell.ColorBlack)
+	}
+}
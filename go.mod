@@ -3,12 +3,14 @@ module github.com/spenserblack/termage
 go 1.20
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/MakeNowJust/heredoc/v2 v2.0.1
 	github.com/disintegration/imaging v1.6.2
 	github.com/gdamore/tcell/v2 v2.6.0
 	github.com/imretro/go v1.0.4
 	github.com/spenserblack/go-wordwrap v1.0.1
 	github.com/spf13/cobra v1.8.0
+	github.com/spf13/pflag v1.0.5
 )
 
 require (
@@ -19,7 +21,6 @@ require (
 	github.com/rivo/uniseg v0.4.3 // indirect
 	github.com/spenserblack/go-bitio v1.3.0 // indirect
 	github.com/spenserblack/go-byteutils v1.1.1 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/image v0.5.0 // indirect
 	golang.org/x/sys v0.5.0 // indirect
 	golang.org/x/term v0.5.0 // indirect
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spenserblack/termage/internal/files"
+)
+
+// For mocking functions.
+var (
+	trashFile   = files.Trash
+	userHomeDir = os.UserHomeDir
+)
+
+// AskTrash asks the user to confirm moving the current file to the trash.
+func (v *Viewer) askTrash() {
+	filename := v.browser.Current()
+	name := filepath.Base(filename)
+	v.prompt.ask(fmt.Sprintf("Move %s to the trash?", name), func(string) command {
+		if err := trashFile(filename); err != nil {
+			v.prompt.message = err.Error()
+			return nil
+		}
+		v.prompt.message = fmt.Sprintf("Moved %s to the trash", name)
+		return v.removeFile(filename)
+	})
+}
+
+// AskMove asks the user for a directory to move the current file to. The
+// file stops being browsed once it is moved.
+func (v *Viewer) askMove() {
+	filename := v.browser.Current()
+	v.prompt.askFor("Move to:", v.lastDir, func(dir string) command {
+		dir, err := v.useDir(dir)
+		if err != nil {
+			v.prompt.message = err.Error()
+			return nil
+		}
+		moved, err := files.MoveTo(filename, dir)
+		if err != nil {
+			v.prompt.message = err.Error()
+			return nil
+		}
+		v.prompt.message = fmt.Sprintf("Moved to %s", moved)
+		return v.removeFile(filename)
+	})
+}
+
+// AskCopy asks the user for a directory to copy the current file to.
+func (v *Viewer) askCopy() {
+	filename := v.browser.Current()
+	v.prompt.askFor("Copy to:", v.lastDir, func(dir string) command {
+		dir, err := v.useDir(dir)
+		if err != nil {
+			v.prompt.message = err.Error()
+			return nil
+		}
+		copied, err := files.CopyTo(filename, dir)
+		if err != nil {
+			v.prompt.message = err.Error()
+			return nil
+		}
+		v.prompt.message = fmt.Sprintf("Copied to %s", copied)
+		return nil
+	})
+}
+
+// AskRename asks the user for a new name of the current file.
+func (v *Viewer) askRename() {
+	filename := v.browser.Current()
+	v.prompt.askFor("Rename to:", filepath.Base(filename), func(name string) command {
+		renamed, err := files.Rename(filename, name)
+		if err != nil {
+			v.prompt.message = err.Error()
+			return nil
+		}
+		v.prompt.message = fmt.Sprintf("Renamed to %s", name)
+		v.replaceFile(filename, renamed)
+		// NOTE Opens the file again so that the title has its new name
+		return v.open()
+	})
+}
+
+// AskTag asks the user for a tag to give the current file, or to remove from
+// it if it has the tag.
+func (v *Viewer) askTag() {
+	filename := v.browser.Current()
+	v.prompt.askFor("Tag:", v.lastTag, func(tag string) command {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return nil
+		}
+		v.lastTag = tag
+		v.marks.toggleTag(filename, tag)
+		return nil
+	})
+}
+
+// UseDir expands a "~" at the start of a directory that the user typed, and
+// remembers it as the default directory of the next move or copy. An empty
+// answer is an error, so that files aren't moved to the working directory by
+// accident.
+func (v *Viewer) useDir(dir string) (string, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return "", errors.New("No directory was given")
+	}
+	if dir == "~" || strings.HasPrefix(dir, "~"+string(filepath.Separator)) {
+		if home, err := userHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[1:])
+		}
+	}
+	v.lastDir = dir
+	return dir, nil
+}
+
+// RemoveFile stops browsing a file that was moved away, and opens the file
+// that took its place. The viewer quits if no files are left.
+func (v *Viewer) removeFile(filename string) command {
+	for i, f := range v.browser.Filenames {
+		if f == filename {
+			v.slides.remove(i)
+			break
+		}
+	}
+	v.browser.Remove(filename)
+	v.marks.remove(filename)
+	kept := v.errors[:0]
+	for _, e := range v.errors {
+		if e.filename != filename {
+			kept = append(kept, e)
+		}
+	}
+	v.errors = kept
+	if v.unshuffled != nil {
+		unshuffled := files.FileBrowser{Filenames: v.unshuffled}
+		unshuffled.Remove(filename)
+		v.unshuffled = unshuffled.Filenames
+	}
+	if v.browser.IsEmpty() {
+		v.done = true
+		v.closeImage()
+		return nil
+	}
+	v.finder.refresh(v.browser.Filenames)
+	return v.open()
+}
+
+// ReplaceFile browses the new path of a file in place of its old path.
+func (v *Viewer) replaceFile(filename, replacement string) {
+	v.browser.Replace(filename, replacement)
+	v.marks.rename(filename, replacement)
+	if v.unshuffled != nil {
+		unshuffled := files.FileBrowser{Filenames: v.unshuffled}
+		unshuffled.Replace(filename, replacement)
+		v.unshuffled = unshuffled.Filenames
+	}
+	for i := range v.errors {
+		if v.errors[i].filename == filename {
+			v.errors[i].filename = replacement
+		}
+	}
+	v.finder.refresh(v.browser.Filenames)
+}
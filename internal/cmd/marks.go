@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Marks are the files that the user marked, and the named tags that the user
+// gave files.
+type marks struct {
+	marked map[string]bool
+	// Tags maps files to their tags.
+	tags map[string]map[string]bool
+}
+
+// ToggleMark marks a file, or unmarks it if it is marked.
+func (m *marks) toggleMark(filename string) {
+	if m.marked == nil {
+		m.marked = make(map[string]bool)
+	}
+	if m.marked[filename] {
+		delete(m.marked, filename)
+	} else {
+		m.marked[filename] = true
+	}
+}
+
+// ToggleTag tags a file, or removes the tag if the file has it.
+func (m *marks) toggleTag(filename, tag string) {
+	if m.tags == nil {
+		m.tags = make(map[string]map[string]bool)
+	}
+	tags := m.tags[filename]
+	if tags[tag] {
+		delete(tags, tag)
+		if len(tags) == 0 {
+			delete(m.tags, filename)
+		}
+		return
+	}
+	if tags == nil {
+		tags = make(map[string]bool)
+		m.tags[filename] = tags
+	}
+	tags[tag] = true
+}
+
+// Label gets the marks of a file for its title, like "* #keep #blurry", or
+// an empty string if it has none. Tags are sorted by name.
+func (m *marks) label(filename string) string {
+	var parts []string
+	if m.marked[filename] {
+		parts = append(parts, "*")
+	}
+	tags := make([]string, 0, len(m.tags[filename]))
+	for tag := range m.tags[filename] {
+		tags = append(tags, "#"+tag)
+	}
+	sort.Strings(tags)
+	return strings.Join(append(parts, tags...), " ")
+}
+
+// Rename moves the marks of a file to its new path.
+func (m *marks) rename(filename, renamed string) {
+	if m.marked[filename] {
+		delete(m.marked, filename)
+		m.marked[renamed] = true
+	}
+	if tags, ok := m.tags[filename]; ok {
+		delete(m.tags, filename)
+		m.tags[renamed] = tags
+	}
+}
+
+// Remove removes the marks of a file.
+func (m *marks) remove(filename string) {
+	delete(m.marked, filename)
+	delete(m.tags, filename)
+}
+
+// Selected gets the files that have any of the tags, or the marked files if
+// there are no tags, in the order of the files.
+func (m *marks) selected(filenames []string, tags []string) []string {
+	var selected []string
+	for _, filename := range filenames {
+		ok := len(tags) == 0 && m.marked[filename]
+		for _, tag := range tags {
+			ok = ok || m.tags[filename][tag]
+		}
+		if ok {
+			selected = append(selected, filename)
+		}
+	}
+	return selected
+}
+
+// PrintSelected prints files, each followed by a newline, or by a NUL
+// character if null is true.
+func printSelected(w io.Writer, filenames []string, null bool) error {
+	separator := "\n"
+	if null {
+		separator = "\x00"
+	}
+	for _, filename := range filenames {
+		if _, err := fmt.Fprint(w, filename, separator); err != nil {
+			return fmt.Errorf("Couldn't print selected files: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestMarksLabel checks that the label of a file would show its mark and its
+// sorted tags, and that toggling would remove them.
+func TestMarksLabel(t *testing.T) {
+	var m marks
+	m.toggleMark("a.png")
+	m.toggleTag("a.png", "keep")
+	m.toggleTag("a.png", "blurry")
+
+	if actual := m.label("a.png"); actual != "* #blurry #keep" {
+		t.Errorf(`label = %q, want "* #blurry #keep"`, actual)
+	}
+	m.toggleMark("a.png")
+	m.toggleTag("a.png", "keep")
+	if actual := m.label("a.png"); actual != "#blurry" {
+		t.Errorf(`label after toggling = %q, want "#blurry"`, actual)
+	}
+	if actual := m.label("b.png"); actual != "" {
+		t.Errorf(`label of an unmarked file = %q, want ""`, actual)
+	}
+}
+
+// TestMarksSelected checks that the marked files, or the files with any of
+// the tags, would be selected in the order of the files.
+func TestMarksSelected(t *testing.T) {
+	var m marks
+	filenames := []string{"a.png", "b.png", "c.png", "d.png"}
+	m.toggleMark("c.png")
+	m.toggleMark("a.png")
+	m.toggleTag("b.png", "keep")
+	m.toggleTag("d.png", "blurry")
+	m.rename("d.png", "e.png")
+	filenames[3] = "e.png"
+
+	if actual := strings.Join(m.selected(filenames, nil), ","); actual != "a.png,c.png" {
+		t.Errorf(`marked files = %s, want a.png,c.png`, actual)
+	}
+	if actual := strings.Join(m.selected(filenames, []string{"blurry", "keep"}), ","); actual != "b.png,e.png" {
+		t.Errorf(`tagged files = %s, want b.png,e.png`, actual)
+	}
+}
+
+// TestPrintSelected checks that files would be printed with newlines or NUL
+// characters after them.
+func TestPrintSelected(t *testing.T) {
+	for null, want := range map[bool]string{false: "a b.png\nc.png\n", true: "a b.png\x00c.png\x00"} {
+		out := new(bytes.Buffer)
+		if err := printSelected(out, []string{"a b.png", "c.png"}, null); err != nil {
+			t.Fatalf(`err = %v, want nil`, err)
+		}
+		if actual := out.String(); actual != want {
+			t.Errorf(`output with null = %v is %q, want %q`, null, actual, want)
+		}
+	}
+}
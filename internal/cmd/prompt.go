@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"github.com/gdamore/tcell/v2"
+)
+
+// Prompt asks the user to confirm an operation, or to type its argument, in
+// place of the title. It also shows the result of the last operation until
+// the next key press.
+type prompt struct {
+	// Active is true while the prompt is open.
+	active   bool
+	question string
+	// Typing is true if the user types an answer, and false for yes or no
+	// questions.
+	typing bool
+	answer []rune
+	// Confirm runs the operation with the answer when the user confirms it.
+	confirm func(answer string) command
+	// Message is the result of the last operation.
+	message string
+}
+
+// Ask opens the prompt with a yes or no question.
+func (p *prompt) ask(question string, confirm func(answer string) command) {
+	*p = prompt{active: true, question: question, confirm: confirm}
+}
+
+// AskFor opens the prompt with a question that the user types an answer to,
+// starting with an answer that they can change.
+func (p *prompt) askFor(question, answer string, confirm func(answer string) command) {
+	*p = prompt{active: true, question: question, typing: true, answer: []rune(answer), confirm: confirm}
+}
+
+// HandleKey updates the prompt from a key press. It returns the command of the
+// operation if the user confirmed it.
+func (p *prompt) handleKey(ev *tcell.EventKey) command {
+	if !p.typing {
+		p.active = false
+		if ev.Key() == tcell.KeyRune && (ev.Rune() == 'y' || ev.Rune() == 'Y') {
+			return p.confirm("")
+		}
+		return nil
+	}
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		p.active = false
+	case tcell.KeyEnter:
+		p.active = false
+		return p.confirm(string(p.answer))
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(p.answer) > 0 {
+			p.answer = p.answer[:len(p.answer)-1]
+		}
+	case tcell.KeyCtrlU:
+		p.answer = p.answer[:0]
+	case tcell.KeyRune:
+		p.answer = append(p.answer, ev.Rune())
+	}
+	return nil
+}
+
+// Hint gets the keys that answer the prompt.
+func (p *prompt) hint() string {
+	if p.typing {
+		return "Enter to confirm, Esc to cancel"
+	}
+	return "y to confirm, any other key to cancel"
+}
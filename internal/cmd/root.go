@@ -1,28 +1,35 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"image"
-	"log"
-	"os"
+	"io"
+	"runtime/debug"
+	"sync"
 	"time"
 
-	"github.com/disintegration/imaging"
 	"github.com/gdamore/tcell/v2"
 
 	"github.com/spenserblack/termage/internal/conversion"
 	"github.com/spenserblack/termage/internal/draw"
 	"github.com/spenserblack/termage/internal/files"
+	"github.com/spenserblack/termage/internal/keys"
+	"github.com/spenserblack/termage/internal/prefetch"
+	"github.com/spenserblack/termage/internal/thumbnail"
+	"github.com/spenserblack/termage/internal/transform"
 	"github.com/spenserblack/termage/internal/utils"
+	"github.com/spenserblack/termage/internal/view"
 	"github.com/spenserblack/termage/pkg/gif"
+	"github.com/spenserblack/termage/pkg/render"
 )
 
 const (
-	pixelHeight float32 = 2.15
+	// ThumbnailSize is the size of cached thumbnails. Images that fit within
+	// this size are fast enough to decode that they aren't cached.
+	thumbnailSize = thumbnail.XLarge
 )
 
-// Screen is the main screen that will be initialized and drawn to.
-var Screen tcell.Screen
-
 // Shift is a wrapper around image.Point that specifies absolute shift
 // or relative.
 type Shift struct {
@@ -30,305 +37,350 @@ type Shift struct {
 	relative bool
 }
 
-// Zoom is used to manage the zoom-level as a percentage.
-type Zoom uint
+// Loaded is an image that has been loaded and should be drawn.
+type loaded struct {
+	image.Image
+	// Fitted is the image pre-scaled to fit the screen, if it was prefetched.
+	fitted *fitted
+	meta   utils.Metadata
+	title  string
+	// Err is the error that the file failed to load with, if any.
+	err error
+	// Decode decodes the full image if the image is a cached thumbnail of
+	// it, which is drawn until it has too few pixels for the view. Size is
+	// the size of the full image.
+	decode func() loaded
+	size   image.Point
+}
+
+// Fitted is an image that has been rendered with a view for an image area of
+// a certain size.
+type fitted struct {
+	area     image.Point
+	view     view.View
+	rendered view.Viewport
+}
+
+// TransformChange changes the transform of the view.
+type transformChange func(transform.Transform) transform.Transform
 
-// Root is the main function to be run by the root command.
-func Root(imageFiles []string, supported map[string]struct{}) {
+// Options are the user's options for the viewer.
+type Options struct {
+	// Filter decides which files are browsed.
+	Filter files.Filter
+	// Bindings maps keys to the actions of the viewer.
+	Bindings keys.Bindings
+	// Converter converts images to colored runes.
+	Converter conversion.Converter
+	// Style is the style images are drawn with.
+	Style draw.Style
+	// Sort is the order files are browsed in.
+	Sort files.SortOrder
+	// Resampling is the filter that images are resampled with when they are
+	// zoomed.
+	Resampling render.Filter
+	// ZoomMode decides the zoom that images are opened at.
+	ZoomMode view.ZoomMode
+	// Zoom is the zoom images are opened at with view.ZoomFixed.
+	Zoom view.Zoom
+	// ScrollStep is the percentage of the image that fast scrolling moves.
+	// 0 is the default of 10 percent.
+	ScrollStep int
+	// Transform is the rotation and flip that images are opened with.
+	Transform transform.Transform
+	// SkipBroken moves past files that fail to load, in the direction that
+	// the user was browsing in.
+	SkipBroken bool
+	// MarkBroken marks files that failed to load in the title and in the
+	// matches of searches.
+	MarkBroken bool
+	// Slideshow starts a slideshow that shows each image for an interval.
+	// 0 doesn't start a slideshow.
+	Slideshow time.Duration
+	// SlideshowShuffle shows the slideshow in a random order.
+	SlideshowShuffle bool
+	// SlideshowStopAtEnd stops the slideshow once each image was shown,
+	// instead of starting over.
+	SlideshowStopAtEnd bool
+	// Shuffle shuffles the files before they are browsed.
+	Shuffle bool
+	// Seed decides the order of shuffled files and the random images, so
+	// that they can be repeated.
+	Seed int64
+	// Output is where the marked files are printed when the viewer quits,
+	// or nil to print nothing.
+	Output io.Writer
+	// Warnings is where problems that don't stop the viewer, like
+	// thumbnails that couldn't be saved, are reported when it quits, or nil
+	// to report nothing.
+	Warnings io.Writer
+	// PrintNull separates the printed files with NUL characters instead of
+	// newlines.
+	PrintNull bool
+	// PrintTags prints the files with any of the tags instead of the marked
+	// files.
+	PrintTags []string
+}
+
+// Root is the main function to be run by the root command. It returns errors
+// that keep the viewer from starting. Files that fail to load are listed in
+// the viewer instead.
+func Root(imageFiles []string, supported map[string]struct{}, options Options) error {
 	var browser files.FileBrowser
 	var err error
 
+	if options.Bindings == nil {
+		options.Bindings = keys.DefaultBindings()
+	}
+	if options.ScrollStep == 0 {
+		options.ScrollStep = 10
+	}
+
 	if len(imageFiles) == 1 {
-		browser, err = files.NewFileBrowser(imageFiles[0], supported)
+		browser, err = files.NewFilteredFileBrowser(imageFiles[0], supported, options.Filter)
 	} else {
-		browser = files.FileBrowser{Filenames: imageFiles}
+		browser = files.FileBrowser{}
+		browser.Filenames, err = options.Filter.Apply(imageFiles)
 	}
 
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	if browser.IsEmpty() {
-		log.Fatalf("No valid images found in %q", imageFiles[0])
+		return fmt.Errorf("No valid images found in %q", imageFiles[0])
 	}
-	var (
-		// Modifiers for x and y coordinates of image
-		xMod, yMod  int
-		images      chan image.Image = make(chan image.Image, 1)
-		titleChan   chan string      = make(chan string, 1)
-		errChan     chan error       = make(chan error, 1)
-		doRedraw    chan struct{}    = make(chan struct{}, 1)
-		shiftImg    chan Shift       = make(chan Shift)
-		resetImg    chan struct{}    = make(chan struct{})
-		resetScreen chan struct{}    = make(chan struct{})
-		zoomIn      chan struct{}    = make(chan struct{})
-		zoomOut     chan struct{}    = make(chan struct{})
-	)
-
-	Screen, err = tcell.NewScreen()
+	if err := browser.Sort(options.Sort); err != nil {
+		return err
+	}
+	screen, err := tcell.NewScreen()
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("Couldn't create screen: %w", err)
 	}
-	if err := Screen.Init(); err != nil {
-		log.Fatal(err)
+	if err := screen.Init(); err != nil {
+		return fmt.Errorf("Couldn't initialize screen: %w", err)
 	}
-	Screen.SetStyle(tcell.StyleDefault)
+	defer func() {
+		// NOTE Restores the terminal before a panic is printed
+		r := recover()
+		screen.Fini()
+		if r != nil {
+			panic(r)
+		}
+	}()
+	screen.SetStyle(tcell.StyleDefault)
+	screen.EnableMouse()
 
-	loadImage := func() {
-		resetScreen <- struct{}{}
-		m, title, err := utils.LoadImage(browser.Current())
-		titleChan <- title
-		if err != nil && err != utils.ErrNotAnimated {
-			errChan <- err
-			return
+	thumbnails, thumbnailsErr := thumbnail.DefaultCache()
+	prefetcher := prefetch.New(safeLoader(utils.LoadImageMetadata), prefetch.DefaultBudget)
+	defer prefetcher.Stop()
+	prefetcher.Prepare = func(m image.Image) interface{} {
+		if _, ok := m.(*gif.Helper); ok {
+			return nil
 		}
-		images <- m
+		area := imageArea(screen)
+		m = options.Transform.Apply(m)
+		zoom := options.ZoomMode.Zoom(area, m.Bounds(), options.Zoom)
+		rect := view.RenderRect(image.Point{}, zoom.Size(m.Bounds()), area)
+		prepared := view.View{Transform: options.Transform, Zoom: zoom, Resampling: options.Resampling, Rect: rect}
+		return &fitted{area, prepared, view.RenderViewport(m, prepared, options.Converter)}
 	}
 
-	go func() {
-		go loadImage()
-		var (
-			fitZoom, currentZoom        Zoom
-			title                       string
-			currentImage                image.Image
-			stopAnimation               chan struct{}            = make(chan struct{}, 1)
-			nextFrame                   chan conversion.RGBRunes = make(chan conversion.RGBRunes)
-			zoomChan                    chan Zoom                = make(chan Zoom)
-			rgbRunes                    conversion.RGBRunes
-			currentWidth, currentHeight int
-		)
-		zoomGif := func() {
-			zoomChan <- currentZoom
+	var saveErrs saveErrors
+	// NOTE Decode saves the thumbnail of large images, unless it was cached
+	decode := func(filename string, cached bool) loaded {
+		entry := prefetcher.Get(filename)
+		l := loaded{Image: entry.Image, meta: entry.Metadata, title: entry.Title}
+		if err := entry.Err; err != nil && err != utils.ErrNotAnimated {
+			l.err = err
+			return l
 		}
-		for {
-			select {
-			case <-resetScreen:
-				xMod = 0
-				yMod = 0
-				stopAnimation <- struct{}{}
-				stopAnimation = make(chan struct{}, 1)
-				nextFrame = make(chan conversion.RGBRunes)
-			case currentImage = <-images:
-				maxWidth, maxHeight := Screen.Size()
-				maxWidth = int(float32(maxWidth) / pixelHeight)
-				if maxWidth < maxHeight {
-					currentZoom = Zoom(uint(maxWidth) * 100 / uint(currentImage.Bounds().Max.X))
-				} else {
-					currentZoom = Zoom(uint(maxHeight) * 100 / uint(currentImage.Bounds().Max.Y))
-				}
-				if currentZoom > 100 {
-					currentZoom = 100
-				}
-				fitZoom = currentZoom
-				if g, ok := currentImage.(*gif.Helper); ok {
-					zoomChan = make(chan Zoom, 1)
-					go AnimateGif(g, nextFrame, stopAnimation, zoomChan)
-					go zoomGif()
-					continue
-				}
-				resizedImage := currentZoom.TransImage(currentImage)
-				rgbRunes = conversion.RGBRunesFromImage(resizedImage)
-				currentWidth, currentHeight = rgbRunes.Width(), rgbRunes.Height()
-				draw.Image(Screen, rgbRunes, image.Point{xMod, yMod})
-			case title = <-titleChan:
-				draw.Title(Screen, title)
-			case err := <-errChan:
-				Screen.Clear()
-				draw.Error(Screen, err)
-				Screen.Show()
-			case <-doRedraw:
-				draw.Image(Screen, rgbRunes, image.Point{xMod, yMod})
-			case <-zoomIn:
-				if currentZoom < fitZoom && fitZoom < currentZoom+10 {
-					currentZoom = fitZoom
-				} else {
-					currentZoom += 10
-				}
-				if _, ok := currentImage.(*gif.Helper); ok {
-					go zoomGif()
-					continue
-				}
-				resizedImage := currentZoom.TransImage(currentImage)
-				rgbRunes = conversion.RGBRunesFromImage(resizedImage)
-				currentWidth, currentHeight = rgbRunes.Width(), rgbRunes.Height()
-				draw.Image(Screen, rgbRunes, image.Point{xMod, yMod})
-			case <-zoomOut:
-				if currentZoom < 11 {
-					currentZoom = 1
-				} else {
-					currentZoom -= 10
-				}
-				xMod /= 10
-				yMod /= 10
-				if _, ok := currentImage.(*gif.Helper); ok {
-					go zoomGif()
-					continue
-				}
-				resizedImage := currentZoom.TransImage(currentImage)
-				rgbRunes = conversion.RGBRunesFromImage(resizedImage)
-				currentWidth, currentHeight = rgbRunes.Width(), rgbRunes.Height()
-				draw.Image(Screen, rgbRunes, image.Point{xMod, yMod})
-			case <-resetImg:
-				xMod = 0
-				yMod = 0
-				maxWidth, maxHeight := Screen.Size()
-				maxWidth = int(float32(maxWidth) / pixelHeight)
-				if currentImage == nil {
-					break
-				}
-				if maxWidth < maxHeight {
-					currentZoom = Zoom(maxWidth * 100 / currentImage.Bounds().Max.X)
-				} else {
-					currentZoom = Zoom(maxHeight * 100 / currentImage.Bounds().Max.Y)
-				}
-				if currentZoom > 100 {
-					currentZoom = 100
-				}
-				fitZoom = currentZoom
-				if _, ok := currentImage.(*gif.Helper); ok {
-					go zoomGif()
-					continue
-				}
-				resizedImage := currentZoom.TransImage(currentImage)
-				rgbRunes = conversion.RGBRunesFromImage(resizedImage)
-				currentWidth, currentHeight = rgbRunes.Width(), rgbRunes.Height()
-				draw.Redraw(Screen, title, rgbRunes, image.Point{xMod, yMod})
-			case shift := <-shiftImg:
-				width, height := Screen.Size()
-				height -= draw.TitleBarPixels
-				x, y := shift.X, shift.Y
-				if shift.relative {
-					x = x * currentWidth / 100
-					y = y * currentHeight / 100
-				}
-				if currentWidth > width {
-					xMod += x
-					if xMod < (width-currentWidth)/2 {
-						xMod = (width - currentWidth) / 2
-					}
-					if xMod > (currentWidth-width)/2 {
-						xMod = (currentWidth - width) / 2
-					}
-				}
-				if currentHeight > height {
-					yMod += y
-					if yMod < (height-currentHeight)/2 {
-						yMod = (height - currentHeight) / 2
-					}
-					if yMod > (currentHeight-height)/2 {
-						yMod = (currentHeight - height) / 2
-					}
+		l.fitted, _ = entry.Prepared.(*fitted)
+		if _, ok := l.Image.(*gif.Helper); ok || cached || thumbnailsErr != nil {
+			return l
+		}
+		if size := l.Bounds().Size(); size.X > int(thumbnailSize) || size.Y > int(thumbnailSize) {
+			go func() {
+				if err := thumbnails.Save(filename, thumbnailSize, l.Image); err != nil {
+					saveErrs.add(filename, err)
 				}
-			case frame := <-nextFrame:
-				go draw.Image(Screen, rgbRunes, image.Point{xMod, yMod})
-				rgbRunes = frame
-				currentWidth, currentHeight = rgbRunes.Width(), rgbRunes.Height()
-			}
+			}()
 		}
-	}()
-	for {
-		switch ev := Screen.PollEvent().(type) {
-		case *tcell.EventResize:
-			resetImg <- struct{}{}
-		case *tcell.EventKey:
-			switch ev.Key() {
-			case tcell.KeyEscape:
-				Screen.Fini()
-				os.Exit(0)
-			case tcell.KeyRune:
-				switch ev.Rune() {
-				case 'n':
-					browser.Forward()
+		return l
+	}
+	load := func(filename string, neighbors []string, preview func(image.Image)) loaded {
+		defer prefetcher.Prefetch(neighbors...)
+		if thumbnailsErr != nil || prefetcher.Has(filename) {
+			return decode(filename, false)
+		}
+		thumb, err := thumbnails.Load(filename, thumbnailSize)
+		if err != nil {
+			return decode(filename, false)
+		}
+		// NOTE The cached thumbnail is drawn in place of the image until it
+		//      has too few pixels, so that large images aren't decoded just
+		//      to fit them to the screen. Animations are always decoded, and
+		//      their thumbnail is drawn while they decode.
+		if title, size, meta, err := utils.LoadMetadata(filename); err == nil && meta.Format != "gif" {
+			return loaded{Image: thumb, meta: meta, title: title, size: size, decode: func() loaded {
+				return decode(filename, true)
+			}}
+		}
+		preview(thumb)
+		return decode(filename, true)
+	}
 
-					loadImage()
-				case 'N':
-					browser.Back()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	width, height := screen.Size()
+	v := newViewer(ctx, &browser, options, load, width, height)
+	run(ctx, screen, v)
+	// NOTE The screen draws on /dev/tty, not on stdout, so the files can be
+	//      printed for pipelines. They are printed once the terminal is
+	//      restored, so that they stay on the terminal.
+	screen.Fini()
+	if options.Warnings != nil {
+		saveErrs.report(options.Warnings)
+	}
+	if options.Output == nil {
+		return nil
+	}
+	return printSelected(options.Output, v.selected(), options.PrintNull)
+}
 
-					loadImage()
-				case 'z':
-					zoomIn <- struct{}{}
-				case 'Z':
-					zoomOut <- struct{}{}
-				case 'f':
-					resetImg <- struct{}{}
-				case 'h':
-					shiftImg <- Shift{image.Point{-1, 0}, false}
-					doRedraw <- struct{}{}
-				case 'H':
-					shiftImg <- Shift{image.Point{-10, 0}, true}
-					doRedraw <- struct{}{}
-				case 'j':
-					shiftImg <- Shift{image.Point{0, 1}, false}
-					doRedraw <- struct{}{}
-				case 'J':
-					shiftImg <- Shift{image.Point{0, 10}, true}
-					doRedraw <- struct{}{}
-				case 'k':
-					shiftImg <- Shift{image.Point{0, -1}, false}
-					doRedraw <- struct{}{}
-				case 'K':
-					shiftImg <- Shift{image.Point{0, -10}, true}
-					doRedraw <- struct{}{}
-				case 'l':
-					shiftImg <- Shift{image.Point{1, 0}, false}
-					doRedraw <- struct{}{}
-				case 'L':
-					shiftImg <- Shift{image.Point{10, 0}, true}
-					doRedraw <- struct{}{}
-				}
-			}
-		}
+// SaveErrors are the errors of thumbnails that couldn't be saved. They are
+// reported when the viewer quits, so that they don't draw over the screen.
+type saveErrors struct {
+	mu    sync.Mutex
+	count int
+	// Filename and err are of the first thumbnail that couldn't be saved.
+	filename string
+	err      error
+}
+
+// Add adds the error of a thumbnail that couldn't be saved.
+func (e *saveErrors) add(filename string, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.count == 0 {
+		e.filename, e.err = filename, err
 	}
+	e.count++
 }
 
-// TransImage transforms an image by a zoom percentage.
-func (percentage Zoom) TransImage(i image.Image) image.Image {
-	bounds := i.Bounds()
-	// NOTE Adjusts width of "pixels" to match height
-	width := float32(bounds.Max.X) * pixelHeight
-	return imaging.Resize(
-		i,
-		int(width)*int(percentage)/100,
-		bounds.Max.Y*int(percentage)/100,
-		imaging.Linear,
-	)
+// Report prints the first error, and the number of thumbnails that couldn't
+// be saved, if any.
+func (e *saveErrors) report(w io.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch e.count {
+	case 0:
+	case 1:
+		fmt.Fprintf(w, "Couldn't save the thumbnail of %q: %v\n", e.filename, e.err)
+	default:
+		fmt.Fprintf(w, "Couldn't save %d thumbnails, like the thumbnail of %q: %v\n", e.count, e.filename, e.err)
+	}
 }
 
-// AnimateGif is a helper to fire off animation events at the correct time.
-func AnimateGif(g *gif.Helper, nextFrame chan conversion.RGBRunes, stop chan struct{}, zoomChan chan Zoom) {
-	index := 0
-	max := len(g.Frames)
-	frames := make([]conversion.RGBRunes, max, max)
-	// NextFrameSem is used to let the animator know when the wait has completed
-	// and the next frame is ready.
-	nextFrameSem := make(chan error, 1)
-	nextFrameSem <- nil
-	zoom := <-zoomChan
-	for i, v := range g.Frames {
-		zoomedImage := zoom.TransImage(v)
-		frames[i] = conversion.RGBRunesFromImage(zoomedImage)
+// SafeLoader wraps a loader so that panics while loading a file, like panics
+// of decoders on broken files, are returned as errors of that file.
+func safeLoader(load prefetch.Loader) prefetch.Loader {
+	return func(filename string) (m image.Image, title string, meta utils.Metadata, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("Couldn't load %q: %v", filename, r)
+			}
+		}()
+		return load(filename)
 	}
-	for {
+}
+
+// EventPanic is posted when a command panics, so that the panic happens
+// again in the goroutine of the viewer, which restores the terminal.
+type eventPanic struct {
+	tcell.EventTime
+	value interface{}
+	stack []byte
+}
+
+// Run opens the current file of a viewer, and then updates and draws the
+// viewer for the events of a screen until the user quits. Commands run in
+// their own goroutines, and send their events to the same loop as the
+// screen's events, so that only this loop changes the viewer and draws.
+// Events that are sent after the context is canceled are dropped.
+//
+// Panics of commands are repeated by run, with the stack of the command.
+func run(ctx context.Context, s tcell.Screen, v *Viewer) {
+	events := make(chan tcell.Event)
+	post := func(ev tcell.Event) {
 		select {
-		case <-stop:
-			return
-		case zoom = <-zoomChan:
-			for i, v := range g.Frames {
-				zoomedImage := zoom.TransImage(v)
-				frames[i] = conversion.RGBRunesFromImage(zoomedImage)
-			}
-		default:
-			if <-nextFrameSem != nil {
+		case events <- ev:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		for {
+			ev := s.PollEvent()
+			if ev == nil {
 				return
 			}
-			nextFrame <- frames[index]
-			go func() {
-				time.Sleep(g.Delay())
-				nextFrameSem <- g.NextFrame()
-			}()
-			index++
-			if index >= max {
-				index = 0
+			post(ev)
+		}
+	}()
+	start := func(c command) {
+		defer func() {
+			if r := recover(); r != nil {
+				ev := &eventPanic{value: r, stack: debug.Stack()}
+				ev.SetEventNow()
+				post(ev)
 			}
+		}()
+		c(post)
+	}
+	c := v.open()
+	for {
+		if c != nil {
+			go start(c)
+		}
+		v.Draw(s)
+		if v.Done() {
+			return
 		}
+		ev := <-events
+		if p, ok := ev.(*eventPanic); ok {
+			panic(fmt.Sprintf("%v\n\n%s", p.value, p.stack))
+		}
+		c = v.Update(ev)
+	}
+}
+
+// PositionTitle prefixes a title with the position of the file, like
+// "[12/340] image.png [png]".
+func positionTitle(index, total int, title string) string {
+	return fmt.Sprintf("[%d/%d] %s", index+1, total, title)
+}
+
+// Repeat gets the number of times a command should be repeated from its
+// count, which is 1 if no count was typed.
+func repeat(count int) int {
+	if count == 0 {
+		return 1
+	}
+	return count
+}
+
+// Clamp limits a value to a range.
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
 	}
+	return v
+}
+
+// ImageArea gets the size of the area of the screen that images are drawn in.
+func imageArea(s tcell.Screen) image.Point {
+	width, height := s.Size()
+	return image.Point{width, height - draw.TitleBarPixels}
 }
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/spenserblack/termage/internal/conversion"
+	"github.com/spenserblack/termage/internal/files"
+	"github.com/spenserblack/termage/internal/keys"
+	"github.com/spenserblack/termage/internal/utils"
+)
+
+// TestPositionTitle checks that the position would be 1-indexed and prefixed
+// to the title.
+func TestPositionTitle(t *testing.T) {
+	actual := positionTitle(11, 340, "image.png [png]")
+	if want := "[12/340] image.png [png]"; actual != want {
+		t.Errorf(`title = %q, want %q`, actual, want)
+	}
+}
+
+// TestRepeat checks that a missing count would repeat a command once.
+func TestRepeat(t *testing.T) {
+	for count, want := range map[int]int{0: 1, 1: 1, 25: 25} {
+		if actual := repeat(count); actual != want {
+			t.Errorf(`repeat(%d) = %d, want %d`, count, actual, want)
+		}
+	}
+}
+
+// TestRun checks that an animation would keep playing while images are
+// scrolled, zoomed and switched, and that the viewer would quit. Events from
+// the screen and from commands are all handled by one loop, which go test
+// -race checks.
+func TestRun(t *testing.T) {
+	s := tcell.NewSimulationScreen("")
+	if err := s.Init(); err != nil {
+		panic(err)
+	}
+	defer s.Fini()
+	s.SetSize(40, 12)
+	// NOTE The same animation is loaded each time, like a cached animation
+	helper := animation()
+	load := func(filename string, neighbors []string, preview func(image.Image)) loaded {
+		if filename == "1" {
+			preview(uniform(20, 10, color.White))
+			return loaded{Image: uniform(40, 20, color.Black), title: filename}
+		}
+		return loaded{Image: helper, title: filename}
+	}
+	browser := &files.FileBrowser{Filenames: []string{"0", "1", "2"}}
+	options := Options{
+		Bindings:   keys.DefaultBindings(),
+		Converter:  conversion.Converter{Mode: conversion.BlockMode},
+		ScrollStep: 10,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v := newViewer(ctx, browser, options, load, 40, 12)
+
+	done := make(chan struct{})
+	go func() {
+		run(ctx, s, v)
+		close(done)
+	}()
+	for i := 0; i < 5; i++ {
+		for _, r := range "zlljZhnzLJ" {
+			press(s, tcell.KeyRune, r)
+		}
+	}
+	press(s, tcell.KeyEscape, 0)
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf(`viewer didn't quit`)
+	}
+	if actual := helper.Index(); actual != 0 {
+		t.Errorf(`frame of the loaded animation = %d, want 0`, actual)
+	}
+}
+
+// Press posts a key press to a screen, waiting while its event queue is full.
+func press(s tcell.Screen, key tcell.Key, r rune) {
+	for s.PostEvent(tcell.NewEventKey(key, r, tcell.ModNone)) != nil {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestRunPanic checks that a panic of a command would be repeated by run, so
+// that the caller can restore the terminal.
+func TestRunPanic(t *testing.T) {
+	s := tcell.NewSimulationScreen("")
+	if err := s.Init(); err != nil {
+		panic(err)
+	}
+	defer s.Fini()
+	load := func(filename string, neighbors []string, preview func(image.Image)) loaded {
+		panic("decoder bug")
+	}
+	browser := &files.FileBrowser{Filenames: []string{"0"}}
+	options := Options{Bindings: keys.DefaultBindings()}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v := newViewer(ctx, browser, options, load, 80, 24)
+
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(fmt.Sprint(r), "decoder bug") {
+			t.Errorf(`recovered %v, want the panic of the command`, r)
+		}
+	}()
+	run(ctx, s, v)
+}
+
+// TestSafeLoader checks that a loader that panics would return an error
+// instead.
+func TestSafeLoader(t *testing.T) {
+	load := safeLoader(func(filename string) (image.Image, string, utils.Metadata, error) {
+		panic("decoder bug")
+	})
+	_, _, _, err := load("broken.png")
+	if err == nil {
+		t.Fatalf(`err = nil, want an error`)
+	}
+	if actual := err.Error(); !strings.Contains(actual, "broken.png") || !strings.Contains(actual, "decoder bug") {
+		t.Errorf(`err = %q, want the filename and the panic`, actual)
+	}
+}
+
+// TestSaveErrors checks that the first error of thumbnails that couldn't be
+// saved would be reported with their number, and that nothing would be
+// reported without errors.
+func TestSaveErrors(t *testing.T) {
+	var e saveErrors
+	var b strings.Builder
+	e.report(&b)
+	if b.Len() != 0 {
+		t.Errorf(`report without errors = %q, want ""`, b.String())
+	}
+	e.add("a.png", errors.New("read-only"))
+	e.add("b.png", errors.New("full"))
+	e.report(&b)
+	if want := "Couldn't save 2 thumbnails, like the thumbnail of \"a.png\": read-only\n"; b.String() != want {
+		t.Errorf(`report = %q, want %q`, b.String(), want)
+	}
+}
+
+// TestRootNoImages checks that Root would return an error, instead of
+// exiting, if there are no images to view.
+func TestRootNoImages(t *testing.T) {
+	dir := t.TempDir()
+	if err := Root([]string{dir}, map[string]struct{}{".png": {}}, Options{}); err == nil {
+		t.Errorf(`err = nil, want an error`)
+	}
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/spenserblack/termage/internal/fuzzy"
+)
+
+// Search is the state of the fuzzy finder over the browsed files.
+type search struct {
+	// Active is true while the search prompt is open.
+	active bool
+	query  []rune
+	// Matches are indices of the matching files, from best to worst match.
+	matches  []int
+	selected int
+}
+
+// Open opens the search prompt with an empty query.
+func (s *search) open(filenames []string) {
+	s.active = true
+	s.query = s.query[:0]
+	s.update(filenames)
+}
+
+// HandleKey updates the search from a key press. It returns the index of the
+// file that should be jumped to, or -1, and whether the prompt was closed.
+func (s *search) handleKey(ev *tcell.EventKey, filenames []string) (jump int, closed bool) {
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		s.active = false
+		return -1, true
+	case tcell.KeyEnter:
+		s.active = false
+		if len(s.matches) == 0 {
+			return -1, true
+		}
+		return s.matches[s.selected], true
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(s.query) > 0 {
+			s.query = s.query[:len(s.query)-1]
+			s.update(filenames)
+		}
+	case tcell.KeyTab, tcell.KeyDown, tcell.KeyCtrlN:
+		if len(s.matches) > 0 {
+			s.selected = (s.selected + 1) % len(s.matches)
+		}
+	case tcell.KeyBacktab, tcell.KeyUp, tcell.KeyCtrlP:
+		if len(s.matches) > 0 {
+			s.selected = (s.selected - 1 + len(s.matches)) % len(s.matches)
+		}
+	case tcell.KeyRune:
+		s.query = append(s.query, ev.Rune())
+		s.update(filenames)
+	}
+	return -1, false
+}
+
+// Match gets the base name of the selected match, or an empty string.
+func (s *search) match(filenames []string) string {
+	if len(s.matches) == 0 {
+		return ""
+	}
+	return filepath.Base(filenames[s.matches[s.selected]])
+}
+
+// Next gets the index of the next file after the current index that matched
+// the last search, wrapping around. If forward is false, it gets the previous
+// matching file instead.
+func (s *search) next(current int, forward bool) (int, bool) {
+	if len(s.matches) == 0 {
+		return 0, false
+	}
+	sorted := append([]int(nil), s.matches...)
+	sort.Ints(sorted)
+	if forward {
+		for _, i := range sorted {
+			if i > current {
+				return i, true
+			}
+		}
+		return sorted[0], true
+	}
+	for i := len(sorted) - 1; i >= 0; i-- {
+		if sorted[i] < current {
+			return sorted[i], true
+		}
+	}
+	return sorted[len(sorted)-1], true
+}
+
+// Update filters the files by the current query.
+func (s *search) update(filenames []string) {
+	names := make([]string, len(filenames))
+	for i, filename := range filenames {
+		names[i] = filepath.Base(filename)
+	}
+	s.matches = fuzzy.Filter(string(s.query), names)
+	s.selected = 0
+}
+
+// Refresh matches the last search again, after the files changed. Nothing is
+// matched if there was no search.
+func (s *search) refresh(filenames []string) {
+	if s.matches == nil {
+		return
+	}
+	s.update(filenames)
+}
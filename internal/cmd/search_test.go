@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var searchFilenames = []string{
+	"/pics/a_diff.png",
+	"/pics/a.png",
+	"/pics/b_diff.png",
+	"/pics/c.png",
+}
+
+// TypeQuery sends each rune of a query to the search.
+func typeQuery(s *search, query string) {
+	for _, r := range query {
+		s.handleKey(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), searchFilenames)
+	}
+}
+
+// TestSearchJump checks that typing a query and pressing enter would jump to
+// the best match.
+func TestSearchJump(t *testing.T) {
+	var s search
+	s.open(searchFilenames)
+	typeQuery(&s, "bdiff")
+
+	if actual, want := s.match(searchFilenames), "b_diff.png"; actual != want {
+		t.Errorf(`match = %q, want %q`, actual, want)
+	}
+
+	jump, closed := s.handleKey(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), searchFilenames)
+
+	if !closed || s.active {
+		t.Errorf(`search was not closed`)
+	}
+	if jump != 2 {
+		t.Errorf(`jump = %d, want 2`, jump)
+	}
+}
+
+// TestSearchCancel checks that escape would close the search without jumping.
+func TestSearchCancel(t *testing.T) {
+	var s search
+	s.open(searchFilenames)
+	typeQuery(&s, "diff")
+
+	jump, closed := s.handleKey(tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone), searchFilenames)
+
+	if !closed || jump != -1 {
+		t.Errorf(`jump, closed = %d, %v, want -1, true`, jump, closed)
+	}
+}
+
+// TestSearchNext checks that the next and previous matches would be found in
+// file order, wrapping around.
+func TestSearchNext(t *testing.T) {
+	var s search
+	s.open(searchFilenames)
+	typeQuery(&s, "diff")
+
+	for _, tt := range []struct {
+		current int
+		forward bool
+		want    int
+	}{
+		{0, true, 2},
+		{2, true, 0},
+		{3, false, 2},
+		{0, false, 2},
+	} {
+		actual, ok := s.next(tt.current, tt.forward)
+		if !ok || actual != tt.want {
+			t.Errorf(`next(%d, %v) = %d, want %d`, tt.current, tt.forward, actual, tt.want)
+		}
+	}
+}
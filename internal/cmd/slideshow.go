@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"math/rand"
+	"time"
+)
+
+// DefaultSlideshowInterval is the time that each image is shown for when the
+// slideshow is started without an interval.
+const defaultSlideshowInterval = 5 * time.Second
+
+// Slideshow moves to the next image on a timer.
+type slideshow struct {
+	// Interval is the time that each image is shown for.
+	interval time.Duration
+	active   bool
+	// Shuffle shows the images in a random order, showing each image once
+	// before any image is shown again.
+	shuffle bool
+	// StopAtEnd stops the slideshow once each image was shown, which is
+	// before it comes back to the image that it started from when not
+	// shuffling.
+	stopAtEnd bool
+	first     int
+	random    *rand.Rand
+	// Runs increases each time that the slideshow is started, so that slide
+	// events of earlier runs are ignored.
+	runs int
+	// Remaining are the indices of the images that are left to show when
+	// shuffling.
+	remaining []int
+}
+
+// NewSlideshow creates a slideshow from the options. The slideshow is
+// started if the options have an interval.
+func newSlideshow(options Options, current, total int) slideshow {
+	s := slideshow{
+		interval:  options.Slideshow,
+		shuffle:   options.SlideshowShuffle,
+		stopAtEnd: options.SlideshowStopAtEnd,
+		random:    rand.New(rand.NewSource(options.Seed)),
+	}
+	if s.interval <= 0 {
+		s.interval = defaultSlideshowInterval
+	} else {
+		s.start(current, total)
+	}
+	return s
+}
+
+// Start starts the slideshow from the image at the current index.
+func (s *slideshow) start(current, total int) {
+	s.active = true
+	s.runs++
+	s.first = current
+	s.remaining = nil
+	if s.shuffle {
+		s.refill(current, total)
+	}
+}
+
+// Refill shuffles the indices of all images except the current image into
+// the remaining images.
+func (s *slideshow) refill(current, total int) {
+	for _, index := range s.random.Perm(total) {
+		if index != current {
+			s.remaining = append(s.remaining, index)
+		}
+	}
+}
+
+// Remove drops the image at an index that is no longer browsed from the
+// remaining images. The images after it move back by one.
+func (s *slideshow) remove(index int) {
+	// NOTE If the first image is removed, the image that took its place was
+	//      shown after it, so the slideshow still stops before it
+	if index < s.first {
+		s.first--
+	}
+	remaining := s.remaining[:0]
+	for _, i := range s.remaining {
+		switch {
+		case i > index:
+			remaining = append(remaining, i-1)
+		case i < index:
+			remaining = append(remaining, i)
+		}
+	}
+	s.remaining = remaining
+}
+
+// Next gets the index of the image after the current image, or false if the
+// slideshow is at its end.
+func (s *slideshow) next(current, total int) (int, bool) {
+	if !s.shuffle {
+		if s.stopAtEnd && (current+1)%total == s.first%total {
+			return 0, false
+		}
+		return current + 1, true
+	}
+	if len(s.remaining) == 0 {
+		if s.stopAtEnd {
+			return 0, false
+		}
+		s.refill(current, total)
+	}
+	// NOTE There is only one image, which is shown again
+	if len(s.remaining) == 0 {
+		return current, true
+	}
+	next := s.remaining[0]
+	s.remaining = s.remaining[1:]
+	return next, true
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestSlideshowNext checks that a slideshow would go through the images in
+// order, and either start over or stop after the last image.
+func TestSlideshowNext(t *testing.T) {
+	s := slideshow{}
+	if index, ok := s.next(1, 3); !ok || index != 2 {
+		t.Errorf(`next = %d, %v, want 2, true`, index, ok)
+	}
+	if index, ok := s.next(2, 3); !ok || index != 3 {
+		t.Errorf(`next after the last image = %d, %v, want 3, true`, index, ok)
+	}
+	s.stopAtEnd = true
+	if _, ok := s.next(2, 3); ok {
+		t.Errorf(`slideshow didn't stop after the last image`)
+	}
+}
+
+// TestSlideshowNextWraps checks that a slideshow that stops at its end would
+// go around to the images before the image that it started from, and stop
+// before it comes back to it.
+func TestSlideshowNextWraps(t *testing.T) {
+	s := slideshow{stopAtEnd: true}
+	s.start(2, 4)
+	if index, ok := s.next(3, 4); !ok || index != 4 {
+		t.Errorf(`next after the last image = %d, %v, want 4, true`, index, ok)
+	}
+	if index, ok := s.next(0, 4); !ok || index != 1 {
+		t.Errorf(`next = %d, %v, want 1, true`, index, ok)
+	}
+	s.remove(0)
+	if _, ok := s.next(0, 3); ok {
+		t.Errorf(`slideshow didn't stop before the image that it started from`)
+	}
+}
+
+// TestSlideshowShuffle checks that a shuffled slideshow would show each image
+// once before stopping at the end.
+func TestSlideshowShuffle(t *testing.T) {
+	s := slideshow{shuffle: true, stopAtEnd: true, random: rand.New(rand.NewSource(1))}
+	s.start(2, 5)
+	shown := map[int]bool{2: true}
+	current := 2
+	for i := 0; i < 4; i++ {
+		index, ok := s.next(current, 5)
+		if !ok {
+			t.Fatalf(`slideshow stopped after %d images, want 4`, i)
+		}
+		if shown[index] {
+			t.Errorf(`image %d was shown twice`, index)
+		}
+		shown[index] = true
+		current = index
+	}
+	if _, ok := s.next(current, 5); ok {
+		t.Errorf(`slideshow didn't stop after each image was shown`)
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/spenserblack/termage/internal/exif"
+	"github.com/spenserblack/termage/internal/utils"
+	"github.com/spenserblack/termage/internal/view"
+	"github.com/spenserblack/termage/pkg/gif"
+	"github.com/spenserblack/termage/pkg/render"
+)
+
+// StatusText describes the current image for the status bar, like
+// "800x600  1.2 MiB  png  zoom 45% linear  offset +0,+0  YCbCr". The size is
+// of the full image, which may be larger than a thumbnail of it.
+func statusText(m image.Image, size image.Point, meta utils.Metadata, zoom view.Zoom, resampling render.Filter, offset image.Point) string {
+	model := m.ColorModel()
+	frames := 0
+	if g, ok := m.(*gif.Helper); ok {
+		// NOTE The first frame is used so that the animation isn't read while
+		// it is playing
+		model = g.Frames[0].ColorModel()
+		frames = len(g.Frames)
+	}
+	parts := []string{fmt.Sprintf("%dx%d", size.X, size.Y)}
+	if meta.FileSize > 0 {
+		parts = append(parts, utils.FormatFileSize(meta.FileSize))
+	}
+	if meta.Format != "" {
+		parts = append(parts, meta.Format)
+	}
+	parts = append(
+		parts,
+		fmt.Sprintf("zoom %v %v", zoom, resampling),
+		fmt.Sprintf("offset %+d,%+d", offset.X, offset.Y),
+		utils.ColorModelName(model),
+	)
+	if frames > 0 {
+		parts = append(parts, fmt.Sprintf("%d frames", frames))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// ErrorLines formats the files that failed to load for the overlay. Errors
+// of loading files name the file, so only the errors are listed.
+func errorLines(errors []fileError) []string {
+	if len(errors) == 0 {
+		return []string{"No files failed to load"}
+	}
+	lines := make([]string, 0, len(errors)+1)
+	lines = append(lines, fmt.Sprintf("%d files failed to load:", len(errors)))
+	for _, e := range errors {
+		lines = append(lines, e.err.Error())
+	}
+	return lines
+}
+
+// ExifLines formats EXIF metadata for the overlay, like "Camera: NIKON D750".
+func exifLines(x *exif.EXIF) []string {
+	if x == nil {
+		return []string{exif.ErrNoEXIF.Error()}
+	}
+	fields := x.Fields()
+	if len(fields) == 0 {
+		return []string{exif.ErrNoEXIF.Error()}
+	}
+	lines := make([]string, len(fields))
+	for i, field := range fields {
+		lines[i] = fmt.Sprintf("%s: %s", field.Name, field.Value)
+	}
+	return lines
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"image"
+	"testing"
+
+	"github.com/spenserblack/termage/internal/exif"
+	"github.com/spenserblack/termage/internal/utils"
+	"github.com/spenserblack/termage/pkg/gif"
+	"github.com/spenserblack/termage/pkg/render"
+)
+
+// TestStatusText checks that the status would contain the size, file size,
+// format, zoom, filter, offset and color model of an image.
+func TestStatusText(t *testing.T) {
+	m := image.NewNRGBA(image.Rect(0, 0, 800, 600))
+	meta := utils.Metadata{Format: "png", FileSize: 1258291}
+
+	actual := statusText(m, m.Bounds().Size(), meta, 45, render.Lanczos, image.Point{-3, 2})
+
+	if want := "800x600  1.2 MiB  png  zoom 45% lanczos  offset -3,+2  NRGBA"; actual != want {
+		t.Errorf(`status = %q, want %q`, actual, want)
+	}
+}
+
+// TestStatusTextFrames checks that the status of an animation would contain
+// the number of frames.
+func TestStatusTextFrames(t *testing.T) {
+	frame := image.NewPaletted(image.Rect(0, 0, 2, 2), nil)
+	m := &gif.Helper{Frames: []gif.Frame{{Image: frame}, {Image: frame}}}
+
+	actual := statusText(m, m.Bounds().Size(), utils.Metadata{}, 100, render.Nearest, image.Point{})
+
+	if want := "2x2  zoom 100% nearest  offset +0,+0  Paletted (0 colors)  2 frames"; actual != want {
+		t.Errorf(`status = %q, want %q`, actual, want)
+	}
+}
+
+// TestExifLines checks that EXIF fields would be listed, and that missing
+// metadata would be explained.
+func TestExifLines(t *testing.T) {
+	lines := exifLines(&exif.EXIF{Make: "Canon", Model: "EOS R6"})
+	if len(lines) != 1 || lines[0] != "Camera: Canon EOS R6" {
+		t.Errorf(`lines = %q, want ["Camera: Canon EOS R6"]`, lines)
+	}
+	lines = exifLines(nil)
+	if len(lines) != 1 || lines[0] != exif.ErrNoEXIF.Error() {
+		t.Errorf(`lines = %q, want [%q]`, lines, exif.ErrNoEXIF)
+	}
+}
@@ -0,0 +1,977 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gdamore/tcell/v2/views"
+
+	"github.com/spenserblack/termage/internal/draw"
+	"github.com/spenserblack/termage/internal/files"
+	"github.com/spenserblack/termage/internal/keys"
+	"github.com/spenserblack/termage/internal/transform"
+	"github.com/spenserblack/termage/internal/utils"
+	"github.com/spenserblack/termage/internal/view"
+	"github.com/spenserblack/termage/pkg/gif"
+	"github.com/spenserblack/termage/pkg/render"
+)
+
+// Command is slow work that is done outside of the viewer, like loading an
+// image. It posts events with the results, which update the viewer. Commands
+// for an image stop when their image is closed.
+type command func(post func(tcell.Event))
+
+// Batch combines commands into one command that runs them at the same time.
+// Nil commands are left out, and nil is returned if all commands are nil.
+func batch(commands ...command) command {
+	var cs []command
+	for _, c := range commands {
+		if c != nil {
+			cs = append(cs, c)
+		}
+	}
+	switch len(cs) {
+	case 0:
+		return nil
+	case 1:
+		return cs[0]
+	}
+	return func(post func(tcell.Event)) {
+		var wg sync.WaitGroup
+		// NOTE Panics are repeated in the goroutine of the batch, so that
+		//      they reach the caller
+		panics := make(chan interface{}, len(cs))
+		for _, c := range cs {
+			wg.Add(1)
+			go func(c command) {
+				defer wg.Done()
+				defer func() {
+					if r := recover(); r != nil {
+						panics <- r
+					}
+				}()
+				c(post)
+			}(c)
+		}
+		wg.Wait()
+		close(panics)
+		if r, ok := <-panics; ok {
+			panic(r)
+		}
+	}
+}
+
+// LoadFunc loads a file, calling preview with a thumbnail of the file, if
+// any, while the full image decodes. Neighbors are the files next to it,
+// which can be loaded ahead of time.
+type loadFunc func(filename string, neighbors []string, preview func(image.Image)) loaded
+
+// EventLoaded is posted when an image, or a thumbnail of it, has loaded.
+type eventLoaded struct {
+	tcell.EventTime
+	// Generation is the generation of the viewer that the image was opened
+	// in.
+	generation int
+	filename   string
+	loaded
+	// Preview is true for thumbnails that are drawn while the full image
+	// decodes.
+	preview bool
+	// Decoded is true for full images that replace the thumbnail that was
+	// drawn in their place.
+	decoded bool
+}
+
+// EventFrame is posted when the next frame of an animation should be drawn.
+type eventFrame struct {
+	tcell.EventTime
+	generation int
+}
+
+// EventSlide is posted when the slideshow should move to the next image.
+type eventSlide struct {
+	tcell.EventTime
+	generation int
+	// Run is the run of the slideshow that the event is for.
+	run int
+}
+
+// Viewer is the state of the image viewer. Update changes the state for each
+// event, and Draw draws the state to a screen.
+type Viewer struct {
+	// Ctx is canceled when the viewer is no longer used.
+	ctx     context.Context
+	options Options
+	browser *files.FileBrowser
+	load    loadFunc
+	// ImageCtx is canceled when the current image is closed, which stops
+	// its commands.
+	imageCtx   context.Context
+	closeImage context.CancelFunc
+	// Size is the size of the screen.
+	size image.Point
+	// Generation increases each time that an image is opened, so that events
+	// for images that were opened before it are ignored.
+	generation int
+	// Filename is the file that was last loaded, which the title is of.
+	filename string
+	title    string
+	image    image.Image
+	meta     utils.Metadata
+	err      error
+	// Thumbnail is true while a thumbnail is drawn in place of the image.
+	// Decode decodes the full image of the thumbnail, unless it is decoding
+	// or the thumbnail is a preview. ImageSize is the size of the full
+	// image, which the status bar reports.
+	thumbnail bool
+	decode    func() loaded
+	imageSize image.Point
+	transform transform.Transform
+	// Transformed is the image after it is transformed, unless it is
+	// animated.
+	transformed image.Image
+	zoomMode    view.ZoomMode
+	zoom        view.Zoom
+	fitZoom     view.Zoom
+	resampling  render.Filter
+	// Offset is the offset of the image from the center of the image area.
+	offset image.Point
+	// Frames are the rendered parts of the frames of the image, or nil for
+	// frames that need to be rendered. Still images have one frame.
+	frames     []*view.Viewport
+	frame      int
+	showStatus bool
+	showEXIF   bool
+	// Errors are the files that failed to load, in the order that they
+	// failed.
+	errors     []fileError
+	showErrors bool
+	// Direction is the direction that the user was browsing in, 1 for
+	// forward and -1 for backward. Broken files are skipped in this
+	// direction.
+	direction int
+	// Skipped is the number of broken files that were skipped in a row.
+	skipped int
+	slides  slideshow
+	// Shuffled is true while the files are shuffled. Unshuffled is the order
+	// of the files before they were shuffled.
+	shuffled   bool
+	unshuffled []string
+	random     *rand.Rand
+	// Looped is true once the animation has played a whole loop, or has
+	// stopped. SlideDue is true if the slideshow is waiting for the loop.
+	looped   bool
+	slideDue bool
+	finder   search
+	prompt   prompt
+	marks    marks
+	// LastDir is the last directory that a file was moved or copied to, and
+	// LastTag is the last tag that a file was given.
+	lastDir string
+	lastTag string
+	// Count is the numeric prefix typed before a command, like 25 in "25g".
+	count int
+	// Buttons are the mouse buttons that were held at the last mouse event.
+	buttons tcell.ButtonMask
+	// Dragging is true while the image is being dragged from the last
+	// position.
+	dragging     bool
+	lastPosition image.Point
+	done         bool
+}
+
+// NewViewer creates a viewer of the files of a browser on a screen of a size.
+// Open opens the first image. Commands stop when the context is canceled.
+func newViewer(ctx context.Context, browser *files.FileBrowser, options Options, load loadFunc, width, height int) *Viewer {
+	v := &Viewer{
+		ctx:        ctx,
+		imageCtx:   ctx,
+		closeImage: func() {},
+		options:    options,
+		browser:    browser,
+		load:       load,
+		size:       image.Point{width, height},
+		transform:  options.Transform,
+		zoomMode:   options.ZoomMode,
+		resampling: options.Resampling,
+		direction:  1,
+		random:     rand.New(rand.NewSource(options.Seed)),
+	}
+	// NOTE The slideshow starts from the position of the current file after
+	//      shuffling
+	v.setShuffled(options.Shuffle)
+	v.slides = newSlideshow(options, browser.Index(), browser.Len())
+	return v
+}
+
+// Done checks if the user quit the viewer.
+func (v *Viewer) Done() bool {
+	return v.done
+}
+
+// Open opens the current file of the browser. The current image stays until
+// the file has loaded.
+func (v *Viewer) open() command {
+	v.closeImage()
+	v.imageCtx, v.closeImage = context.WithCancel(v.ctx)
+	ctx := v.imageCtx
+	v.generation++
+	v.offset = image.Point{}
+	v.decode = nil
+	generation := v.generation
+	filename := v.browser.Current()
+	neighbors := []string{v.browser.Peek(1), v.browser.Peek(-1)}
+	index, total := v.browser.Index(), v.browser.Len()
+	load := v.load
+	return func(post func(tcell.Event)) {
+		l := load(filename, neighbors, func(thumbnail image.Image) {
+			if ctx.Err() == nil {
+				post(newEventLoaded(generation, filename, loaded{Image: thumbnail}, true))
+			}
+		})
+		// NOTE Another image was opened while this one loaded
+		if ctx.Err() != nil {
+			return
+		}
+		l.title = positionTitle(index, total, l.title)
+		post(newEventLoaded(generation, filename, l, false))
+	}
+}
+
+// Update updates the viewer for an event. It returns a command that should
+// run outside of the viewer, or nil.
+func (v *Viewer) Update(ev tcell.Event) (c command) {
+	switch ev := ev.(type) {
+	case *eventLoaded:
+		c = v.show(ev)
+	case *eventFrame:
+		c = v.nextFrame(ev)
+	case *eventSlide:
+		c = v.slide(ev)
+	case *tcell.EventResize:
+		v.resize(ev.Size())
+	case *tcell.EventMouse:
+		c = v.handleMouse(ev)
+	case *tcell.EventKey:
+		c = v.handleKey(ev)
+	}
+	// NOTE The thumbnail has too few pixels once it would be enlarged
+	if v.decode != nil && v.image != nil && v.zoom >= 100 {
+		c = batch(c, v.decodeFull())
+	}
+	v.render()
+	return
+}
+
+// Draw draws the title, the visible part of the image or an error, the
+// status bar, the overlay and the search prompt to a screen.
+func (v *Viewer) Draw(s tcell.Screen) {
+	s.Clear()
+	draw.Title(s, v.label(v.title, v.filename))
+	draw.TitleButtons(s)
+	if v.err != nil {
+		draw.Error(s, v.err)
+	} else if rendered := v.rendered(); rendered != nil {
+		// NOTE Matches how view.VisibleRect centers images in the image area,
+		//      which doesn't include the status bar
+		area, rgbRunes := v.area(), rendered.RGBRunes
+		center := rendered.DrawOffset(v.offset, v.zoomedSize())
+		topLeft := area.Div(2).Sub(image.Point{rgbRunes.Width(), rgbRunes.Height()}.Div(2)).Add(center)
+		canvas := views.NewViewPort(s, 0, draw.TitleBarPixels, area.X, area.Y)
+		draw.ImageAt(canvas, rgbRunes, topLeft, v.options.Style)
+	}
+	if status := v.status(); status != "" {
+		draw.Status(s, status)
+	}
+	if overlay := v.overlay(); overlay != nil {
+		draw.Overlay(s, overlay)
+	}
+	if v.finder.active {
+		match := v.finder.match(v.browser.Filenames)
+		if len(v.finder.matches) > 0 {
+			match = v.label(match, v.browser.Filenames[v.finder.matches[v.finder.selected]])
+		}
+		draw.Search(s, string(v.finder.query), match, v.finder.selected, len(v.finder.matches))
+	} else if v.prompt.active {
+		draw.Prompt(s, v.prompt.question, string(v.prompt.answer), v.prompt.typing, v.prompt.hint())
+	} else {
+		s.HideCursor()
+		if v.prompt.message != "" {
+			draw.Prompt(s, v.prompt.message, "", false, "")
+		}
+	}
+	s.Show()
+}
+
+// Show shows a loaded image, unless another image was opened since it was
+// opened.
+func (v *Viewer) show(ev *eventLoaded) command {
+	if ev.generation != v.generation {
+		return nil
+	}
+	if ev.decoded {
+		return v.replaceThumbnail(ev)
+	}
+	if !ev.preview {
+		v.filename, v.title = ev.filename, ev.title
+	}
+	v.looped, v.slideDue = false, false
+	if ev.err != nil {
+		v.image, v.err = nil, ev.err
+		v.addError(ev.filename, ev.err)
+		// NOTE Stops before going around a second time if every file is
+		//      broken
+		if v.options.SkipBroken && v.skipped < v.browser.Len()-1 {
+			v.skipped++
+			v.browser.Seek(v.browser.Index() + v.direction)
+			return v.open()
+		}
+		return v.nextSlide()
+	}
+	v.skipped = 0
+	v.image, v.meta, v.err = ev.Image, ev.meta, nil
+	v.thumbnail, v.decode = ev.preview || ev.decode != nil, ev.decode
+	if v.imageSize = ev.size; ev.decode == nil {
+		v.imageSize = v.image.Bounds().Size()
+	}
+	v.offset = image.Point{}
+	v.fitZoom = view.FitZoom(v.area(), v.bounds())
+	v.zoom = v.modeZoom()
+	if g, ok := v.image.(*gif.Helper); ok {
+		// NOTE Plays a copy, so that the loaded animation, which may be
+		//      cached, is never changed
+		g = g.Restart()
+		v.image = g
+		v.transformed = nil
+		v.frames = make([]*view.Viewport, len(g.Frames))
+		v.frame = g.Index()
+		return batch(v.tick(g.Delay()), v.nextSlide())
+	}
+	v.transformed = v.transform.Apply(v.image)
+	v.frames = make([]*view.Viewport, 1)
+	v.frame = 0
+	v.useFitted(ev.fitted)
+	return v.nextSlide()
+}
+
+// DecodeFull decodes the full image of the thumbnail that is drawn, which
+// replaces the thumbnail once it has decoded.
+func (v *Viewer) decodeFull() command {
+	decode, ctx, generation, filename := v.decode, v.imageCtx, v.generation, v.filename
+	v.decode = nil
+	return func(post func(tcell.Event)) {
+		l := decode()
+		if ctx.Err() != nil {
+			return
+		}
+		ev := newEventLoaded(generation, filename, l, false)
+		ev.decoded = true
+		post(ev)
+	}
+}
+
+// ReplaceThumbnail draws the full image in place of its thumbnail, keeping
+// the size that the image is drawn at and the part of it that is visible.
+func (v *Viewer) replaceThumbnail(ev *eventLoaded) command {
+	v.thumbnail = false
+	if ev.err != nil {
+		v.image, v.err = nil, ev.err
+		v.addError(ev.filename, ev.err)
+		return nil
+	}
+	wasModeZoom := v.zoom == v.modeZoom()
+	thumbnail := v.bounds()
+	v.image, v.meta, v.imageSize = ev.Image, ev.meta, ev.Image.Bounds().Size()
+	v.fitZoom = view.FitZoom(v.area(), v.bounds())
+	if wasModeZoom {
+		v.zoom = v.modeZoom()
+	} else if v.zoom = v.zoom * view.Zoom(thumbnail.Dx()) / view.Zoom(v.bounds().Dx()); v.zoom < view.MinZoom {
+		v.zoom = view.MinZoom
+	}
+	x, y := view.ClampOffset(v.offset, v.zoomedSize(), v.area())
+	v.offset = image.Point{x, y}
+	v.transformed = v.transform.Apply(v.image)
+	v.invalidate()
+	v.useFitted(ev.fitted)
+	return nil
+}
+
+// UseFitted uses an image that was rendered ahead of time, if it was
+// rendered for the current view.
+func (v *Viewer) useFitted(f *fitted) {
+	if f != nil && f.area == v.area() && f.view == v.currentView() {
+		rendered := f.rendered
+		v.frames[0] = &rendered
+	}
+}
+
+// NextFrame moves the animation to its next frame, unless another image was
+// opened since it started.
+func (v *Viewer) nextFrame(ev *eventFrame) command {
+	g, ok := v.image.(*gif.Helper)
+	if !ok || ev.generation != v.generation {
+		return nil
+	}
+	err := g.NextFrame()
+	if err == nil {
+		v.frame = g.Index()
+	}
+	// NOTE The animation stopped, or went back to its first frame
+	if err != nil || g.Index() == 0 {
+		v.looped = true
+		if v.slideDue && v.slides.active {
+			return v.advance()
+		}
+	}
+	if err != nil {
+		return nil
+	}
+	return v.tick(g.Delay())
+}
+
+// Tick waits for a delay, and then posts the next frame of the animation,
+// unless the image was closed.
+func (v *Viewer) tick(delay time.Duration) command {
+	generation := v.generation
+	return v.after(delay, func() tcell.Event {
+		return newEventFrame(generation)
+	})
+}
+
+// NextSlide waits for the interval of the slideshow, and then posts a slide
+// event, unless the image was closed. It returns nil if the slideshow is
+// stopped.
+func (v *Viewer) nextSlide() command {
+	if !v.slides.active {
+		return nil
+	}
+	generation, run := v.generation, v.slides.runs
+	return v.after(v.slides.interval, func() tcell.Event {
+		ev := &eventSlide{generation: generation, run: run}
+		ev.SetEventNow()
+		return ev
+	})
+}
+
+// After waits for a delay, and then posts an event, unless the image was
+// closed.
+func (v *Viewer) after(delay time.Duration, event func() tcell.Event) command {
+	ctx := v.imageCtx
+	return func(post func(tcell.Event)) {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			post(event())
+		case <-ctx.Done():
+		}
+	}
+}
+
+// Slide moves the slideshow to the next image. Animations are moved on from
+// once they have played a whole loop.
+func (v *Viewer) slide(ev *eventSlide) command {
+	if !v.slides.active || ev.generation != v.generation || ev.run != v.slides.runs {
+		return nil
+	}
+	if _, ok := v.image.(*gif.Helper); ok && !v.looped {
+		v.slideDue = true
+		return nil
+	}
+	return v.advance()
+}
+
+// Advance opens the next image of the slideshow, or stops the slideshow at
+// its end.
+func (v *Viewer) advance() command {
+	index, ok := v.slides.next(v.browser.Index(), v.browser.Len())
+	if !ok {
+		v.slides.active = false
+		return nil
+	}
+	return v.jumpTo(index, 1)
+}
+
+// Resize resizes the viewer to the size of the screen. Images are centered,
+// and zoomed to the zoom mode.
+func (v *Viewer) resize(width, height int) {
+	v.size = image.Point{width, height}
+	v.offset = image.Point{}
+	if v.image == nil {
+		return
+	}
+	v.fitZoom = view.FitZoom(v.area(), v.bounds())
+	v.zoom = v.modeZoom()
+	v.invalidate()
+}
+
+// ToggleStatus shows or hides the status bar, which takes the last row of the
+// image area. The zoom follows the zoom mode, unless it was changed.
+func (v *Viewer) toggleStatus() {
+	if v.image == nil {
+		v.showStatus = !v.showStatus
+		return
+	}
+	wasModeZoom := v.zoom == v.modeZoom()
+	v.showStatus = !v.showStatus
+	v.fitZoom = view.FitZoom(v.area(), v.bounds())
+	if wasModeZoom {
+		v.zoom = v.modeZoom()
+	}
+	x, y := view.ClampOffset(v.offset, v.zoomedSize(), v.area())
+	v.offset = image.Point{x, y}
+	v.invalidate()
+}
+
+// HandleMouse zooms at the cursor with the mouse wheel, drags the image with
+// the left button, and goes to the previous or next image with the title bar
+// buttons.
+func (v *Viewer) handleMouse(ev *tcell.EventMouse) command {
+	x, y := ev.Position()
+	position := image.Point{x, y}
+	pressed := ev.Buttons() &^ v.buttons
+	v.buttons = ev.Buttons()
+	if v.finder.active {
+		return nil
+	}
+	// NOTE Positions on the screen are converted to positions in the image
+	//      area to zoom at
+	anchor := position.Sub(image.Point{0, draw.TitleBarPixels})
+	switch {
+	case v.buttons&tcell.WheelUp != 0:
+		v.stepZoom(true, anchor)
+	case v.buttons&tcell.WheelDown != 0:
+		v.stepZoom(false, anchor)
+	case pressed&tcell.Button1 != 0:
+		if button := draw.TitleButtonAt(v.size.X, x, y); button != 0 {
+			return v.jumpTo(v.browser.Index()+button, button)
+		}
+		v.dragging = true
+		v.lastPosition = position
+	case v.buttons&tcell.Button1 != 0 && v.dragging:
+		v.scroll(Shift{position.Sub(v.lastPosition), false})
+		v.lastPosition = position
+	case v.buttons&tcell.Button1 == 0:
+		v.dragging = false
+	}
+	return nil
+}
+
+// HandleKey runs the action bound to a key, with the count typed before it.
+// Keys go to the search prompt while it is open.
+func (v *Viewer) handleKey(ev *tcell.EventKey) command {
+	if v.prompt.active {
+		return v.prompt.handleKey(ev)
+	}
+	v.prompt.message = ""
+	if v.finder.active {
+		jump, closed := v.finder.handleKey(ev, v.browser.Filenames)
+		if closed && jump >= 0 {
+			return v.jumpTo(jump, 1)
+		}
+		return nil
+	}
+	// NOTE Commands use the count, if any, and then reset it
+	prefix := v.count
+	v.count = 0
+	action, ok := v.options.Bindings.Action(ev)
+	if !ok {
+		if r := ev.Rune(); ev.Key() == tcell.KeyRune && r >= '0' && r <= '9' {
+			v.count = prefix*10 + int(r-'0')
+		}
+		return nil
+	}
+	step := v.options.ScrollStep
+	center := v.area().Div(2)
+	switch action {
+	case keys.Quit:
+		v.done = true
+		v.closeImage()
+	case keys.Next:
+		return v.jumpTo(v.browser.Index()+repeat(prefix), 1)
+	case keys.Previous:
+		return v.jumpTo(v.browser.Index()-repeat(prefix), -1)
+	case keys.First:
+		if prefix > 0 {
+			return v.jumpTo(clamp(prefix-1, 0, v.browser.Len()-1), 1)
+		}
+		return v.jumpTo(0, 1)
+	case keys.Last:
+		// NOTE Skips backward, so that a broken last file goes to the last
+		//      file that loads
+		if prefix > 0 {
+			return v.jumpTo(clamp(prefix-1, 0, v.browser.Len()-1), -1)
+		}
+		return v.jumpTo(v.browser.Len()-1, -1)
+	case keys.Random:
+		return v.jumpTo(v.randomIndex(), 1)
+	case keys.ToggleShuffle:
+		v.setShuffled(!v.shuffled)
+		// NOTE Opens the file again so that its title has its new position
+		return v.open()
+	case keys.ToggleMark:
+		v.marks.toggleMark(v.browser.Current())
+	case keys.Tag:
+		v.askTag()
+	case keys.Trash:
+		v.askTrash()
+	case keys.MoveFile:
+		v.askMove()
+	case keys.CopyFile:
+		v.askCopy()
+	case keys.RenameFile:
+		v.askRename()
+	case keys.Search:
+		v.finder.open(v.browser.Filenames)
+	case keys.NextMatch:
+		if index, ok := v.finder.next(v.browser.Index(), true); ok {
+			return v.jumpTo(index, 1)
+		}
+	case keys.PreviousMatch:
+		if index, ok := v.finder.next(v.browser.Index(), false); ok {
+			return v.jumpTo(index, -1)
+		}
+	case keys.ZoomIn:
+		v.stepZoom(true, center)
+	case keys.ZoomOut:
+		v.stepZoom(false, center)
+	case keys.Fit:
+		v.setZoomMode(view.ZoomFit)
+	case keys.FitWidth:
+		v.setZoomMode(view.ZoomFitWidth)
+	case keys.FitHeight:
+		v.setZoomMode(view.ZoomFitHeight)
+	case keys.ActualSize:
+		v.setZoomMode(view.ZoomActualSize)
+	case keys.NextFilter:
+		v.resampling = v.resampling.Next()
+		v.invalidate()
+	case keys.ToggleStatus:
+		v.toggleStatus()
+	case keys.ToggleEXIF:
+		v.showEXIF = !v.showEXIF
+	case keys.ToggleErrors:
+		v.showErrors = !v.showErrors
+	case keys.ToggleSlideshow:
+		if v.slides.active {
+			v.slides.active, v.slideDue = false, false
+			return nil
+		}
+		v.slides.start(v.browser.Index(), v.browser.Len())
+		return v.nextSlide()
+	case keys.RotateClockwise:
+		v.changeTransform(func(t transform.Transform) transform.Transform {
+			return t.Rotate(90)
+		})
+	case keys.RotateCounterClockwise:
+		v.changeTransform(func(t transform.Transform) transform.Transform {
+			return t.Rotate(-90)
+		})
+	case keys.FlipHorizontal:
+		v.changeTransform(transform.Transform.FlipHorizontal)
+	case keys.FlipVertical:
+		v.changeTransform(transform.Transform.FlipVertical)
+	case keys.ScrollLeft:
+		v.scroll(Shift{image.Point{-1, 0}, false})
+	case keys.ScrollLeftFast:
+		v.scroll(Shift{image.Point{-step, 0}, true})
+	case keys.ScrollDown:
+		v.scroll(Shift{image.Point{0, 1}, false})
+	case keys.ScrollDownFast:
+		v.scroll(Shift{image.Point{0, step}, true})
+	case keys.ScrollUp:
+		v.scroll(Shift{image.Point{0, -1}, false})
+	case keys.ScrollUpFast:
+		v.scroll(Shift{image.Point{0, -step}, true})
+	case keys.ScrollRight:
+		v.scroll(Shift{image.Point{1, 0}, false})
+	case keys.ScrollRightFast:
+		v.scroll(Shift{image.Point{step, 0}, true})
+	}
+	return nil
+}
+
+// RandomIndex gets the index of a random file other than the current file,
+// unless it is the only file.
+func (v *Viewer) randomIndex() int {
+	if v.browser.Len() < 2 {
+		return v.browser.Index()
+	}
+	index := v.random.Intn(v.browser.Len() - 1)
+	if index >= v.browser.Index() {
+		index++
+	}
+	return index
+}
+
+// SetShuffled shuffles the files of the browser with the seed, or restores
+// their order, staying on the current file.
+func (v *Viewer) setShuffled(shuffled bool) {
+	if shuffled == v.shuffled || v.browser.IsEmpty() {
+		return
+	}
+	v.shuffled = shuffled
+	remaining := make([]string, len(v.slides.remaining))
+	for i, index := range v.slides.remaining {
+		remaining[i] = v.browser.Filenames[index]
+	}
+	first := v.browser.Filenames[v.slides.first%v.browser.Len()]
+	if shuffled {
+		v.unshuffled = append([]string(nil), v.browser.Filenames...)
+		v.browser.Shuffle(v.options.Seed)
+	} else {
+		current := v.browser.Current()
+		v.browser.Filenames, v.unshuffled = v.unshuffled, nil
+		v.browser.Select(current)
+	}
+	// NOTE Matches and the slides are indices of files, which were moved
+	v.finder.refresh(v.browser.Filenames)
+	indices := make(map[string]int, v.browser.Len())
+	for i, filename := range v.browser.Filenames {
+		indices[filename] = i
+	}
+	v.slides.first = indices[first]
+	v.slides.remaining = v.slides.remaining[:0]
+	for _, filename := range remaining {
+		v.slides.remaining = append(v.slides.remaining, indices[filename])
+	}
+}
+
+// JumpTo opens the file at an index of the browser. Broken files are skipped
+// in the direction, 1 for forward and -1 for backward, since the index can't
+// tell which way the user went when browsing wraps around.
+func (v *Viewer) jumpTo(index, direction int) command {
+	v.direction = direction
+	v.skipped = 0
+	v.browser.Seek(index)
+	return v.open()
+}
+
+// StepZoom zooms in or out by a step, keeping the point of the image under the
+// anchor, a point in the image area, in place.
+func (v *Viewer) stepZoom(in bool, anchor image.Point) {
+	if v.image == nil {
+		return
+	}
+	v.rezoom(v.zoom.Step(v.fitZoom, in), anchor)
+}
+
+// SetZoomMode zooms the image to a zoom mode, which is kept for the next
+// images.
+func (v *Viewer) setZoomMode(mode view.ZoomMode) {
+	v.zoomMode = mode
+	if v.image == nil {
+		return
+	}
+	v.rezoom(v.modeZoom(), v.area().Div(2))
+}
+
+// Rezoom zooms the image, keeping the point of the image under the anchor in
+// place.
+func (v *Viewer) rezoom(zoom view.Zoom, anchor image.Point) {
+	x, y := view.AnchorZoom(anchor, v.offset, v.area(), v.bounds(), v.zoom, zoom)
+	v.offset = image.Point{x, y}
+	v.zoom = zoom
+	v.invalidate()
+}
+
+// ChangeTransform changes the transform of the view. The image is centered,
+// and the zoom follows the zoom mode, unless it was changed.
+func (v *Viewer) changeTransform(change transformChange) {
+	if v.image == nil {
+		v.transform = change(v.transform)
+		return
+	}
+	wasModeZoom := v.zoom == v.modeZoom()
+	v.transform = change(v.transform)
+	v.offset = image.Point{}
+	v.fitZoom = view.FitZoom(v.area(), v.bounds())
+	if wasModeZoom {
+		v.zoom = v.modeZoom()
+	}
+	if _, ok := v.image.(*gif.Helper); !ok {
+		v.transformed = v.transform.Apply(v.image)
+	}
+	v.invalidate()
+}
+
+// Scroll moves the image, without scrolling past its edges. Relative shifts
+// are percentages of the size of the zoomed image.
+func (v *Viewer) scroll(shift Shift) {
+	if v.image == nil {
+		return
+	}
+	d, size := shift.Point, v.zoomedSize()
+	if shift.relative {
+		d = image.Point{d.X * size.X / 100, d.Y * size.Y / 100}
+	}
+	x, y := view.ClampOffset(v.offset.Add(d), size, v.area())
+	v.offset = image.Point{x, y}
+}
+
+// Render renders the visible part of the current frame, unless it has been
+// rendered. Only scrolling past the rendered margin renders the frame again.
+func (v *Viewer) render() {
+	if v.image == nil || v.err != nil {
+		return
+	}
+	if r := v.frames[v.frame]; r != nil && r.Covers(v.offset, v.zoomedSize(), v.area()) {
+		return
+	}
+	var rendered view.Viewport
+	if g, ok := v.image.(*gif.Helper); ok {
+		rendered = v.currentView().Render(g.Frames[v.frame], v.options.Converter)
+	} else {
+		rendered = view.RenderViewport(v.transformed, v.currentView(), v.options.Converter)
+	}
+	v.frames[v.frame] = &rendered
+}
+
+// Invalidate marks all frames to be rendered again.
+func (v *Viewer) invalidate() {
+	for i := range v.frames {
+		v.frames[i] = nil
+	}
+}
+
+// Rendered gets the rendered part of the current frame, or nil if there is
+// no image.
+func (v *Viewer) rendered() *view.Viewport {
+	if v.image == nil {
+		return nil
+	}
+	return v.frames[v.frame]
+}
+
+// CurrentView gets the current view, which renders the visible part of the
+// image.
+func (v *Viewer) currentView() view.View {
+	rect := view.RenderRect(v.offset, v.zoomedSize(), v.area())
+	return view.View{Transform: v.transform, Zoom: v.zoom, Resampling: v.resampling, Rect: rect}
+}
+
+// Area gets the size of the image area of the screen, without the last row
+// while the status bar is shown.
+func (v *Viewer) area() image.Point {
+	area := image.Point{v.size.X, v.size.Y - draw.TitleBarPixels}
+	if v.showStatus {
+		area.Y--
+	}
+	return area
+}
+
+// Bounds gets the bounds of the image after it is transformed.
+func (v *Viewer) bounds() image.Rectangle {
+	return v.transform.Bounds(v.image.Bounds())
+}
+
+// ZoomedSize gets the size of the image in cells after it is transformed and
+// zoomed.
+func (v *Viewer) zoomedSize() image.Point {
+	return v.zoom.Size(v.bounds())
+}
+
+// ModeZoom gets the zoom of the image for the zoom mode.
+func (v *Viewer) modeZoom() view.Zoom {
+	return v.zoomMode.Zoom(v.area(), v.bounds(), v.options.Zoom)
+}
+
+// Status gets the text of the status bar, or an empty string if it is
+// hidden.
+func (v *Viewer) status() string {
+	if !v.showStatus || v.image == nil {
+		return ""
+	}
+	// NOTE The zoom of thumbnails is reported relative to the full image
+	zoom := v.zoom * view.Zoom(v.image.Bounds().Dx()) / view.Zoom(v.imageSize.X)
+	text := statusText(v.image, v.imageSize, v.meta, zoom, v.resampling, v.offset)
+	if v.thumbnail {
+		text += "  thumbnail"
+	}
+	if v.shuffled {
+		text += fmt.Sprintf("  shuffled with seed %d", v.options.Seed)
+	}
+	return text
+}
+
+// Overlay gets the lines of the overlay, or nil if it is hidden. The files
+// that failed to load are shown over the EXIF metadata.
+func (v *Viewer) overlay() []string {
+	switch {
+	case v.showErrors:
+		return errorLines(v.errors)
+	case v.showEXIF && v.image != nil:
+		return exifLines(v.meta.EXIF)
+	}
+	return nil
+}
+
+// AddError adds a file that failed to load to the errors, replacing the
+// error of the file if it already failed.
+func (v *Viewer) addError(filename string, err error) {
+	for i := range v.errors {
+		if v.errors[i].filename == filename {
+			v.errors[i].err = err
+			return
+		}
+	}
+	v.errors = append(v.errors, fileError{filename, err})
+}
+
+// Label adds the marks and tags of a file to the text that names it, like its
+// title, and marks it if it failed to load and broken files are marked.
+func (v *Viewer) label(text string, filename string) string {
+	if text == "" {
+		return text
+	}
+	if v.options.MarkBroken {
+		for _, e := range v.errors {
+			if e.filename == filename {
+				text += " " + brokenMark
+				break
+			}
+		}
+	}
+	if label := v.marks.label(filename); label != "" {
+		text += " " + label
+	}
+	return text
+}
+
+// Selected gets the files that should be printed when the viewer quits, in
+// the order that they were browsed in before they were shuffled.
+func (v *Viewer) selected() []string {
+	filenames := v.browser.Filenames
+	if v.shuffled {
+		filenames = v.unshuffled
+	}
+	return v.marks.selected(filenames, v.options.PrintTags)
+}
+
+// BrokenMark is added to the names of files that failed to load.
+const brokenMark = "[broken]"
+
+// FileError is a file that failed to load.
+type fileError struct {
+	filename string
+	err      error
+}
+
+// NewEventLoaded creates an eventLoaded at the current time.
+func newEventLoaded(generation int, filename string, l loaded, preview bool) *eventLoaded {
+	ev := &eventLoaded{generation: generation, filename: filename, loaded: l, preview: preview}
+	ev.SetEventNow()
+	return ev
+}
+
+// NewEventFrame creates an eventFrame at the current time.
+func newEventFrame(generation int) *eventFrame {
+	ev := &eventFrame{generation: generation}
+	ev.SetEventNow()
+	return ev
+}
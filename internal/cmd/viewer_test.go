@@ -0,0 +1,910 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	stdgif "image/gif"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/spenserblack/termage/internal/conversion"
+	"github.com/spenserblack/termage/internal/draw"
+	"github.com/spenserblack/termage/internal/files"
+	"github.com/spenserblack/termage/internal/keys"
+	"github.com/spenserblack/termage/internal/view"
+	"github.com/spenserblack/termage/pkg/gif"
+)
+
+// NewTestViewer creates a viewer of images on a simulation screen, and opens
+// the first image. Files are named by the index of their image, and nil
+// images fail to load.
+func newTestViewer(images ...image.Image) (*Viewer, tcell.SimulationScreen) {
+	s := tcell.NewSimulationScreen("")
+	if err := s.Init(); err != nil {
+		panic(err)
+	}
+	s.SetSize(80, 24)
+	browser := &files.FileBrowser{}
+	for i := range images {
+		browser.Filenames = append(browser.Filenames, strconv.Itoa(i))
+	}
+	load := func(filename string, neighbors []string, preview func(image.Image)) loaded {
+		i, _ := strconv.Atoi(filename)
+		if images[i] == nil {
+			return loaded{title: filename, err: errors.New("broken")}
+		}
+		return loaded{Image: images[i], title: filename}
+	}
+	options := Options{
+		Bindings:   keys.DefaultBindings(),
+		Converter:  conversion.Converter{Mode: conversion.BlockMode},
+		ScrollStep: 10,
+	}
+	v := newViewer(context.Background(), browser, options, load, 80, 24)
+	settle(v, v.open())
+	return v, s
+}
+
+// Settle runs a command, and updates a viewer with the events that it posts,
+// until no more commands are returned. Frames of animations and slides of
+// slideshows are returned instead, so that tests decide when they happen.
+func settle(v *Viewer, c command) (frames []tcell.Event) {
+	for c != nil {
+		var posted []tcell.Event
+		c(func(ev tcell.Event) {
+			posted = append(posted, ev)
+		})
+		c = nil
+		for _, ev := range posted {
+			switch ev.(type) {
+			case *eventFrame, *eventSlide:
+				frames = append(frames, ev)
+				continue
+			}
+			if next := v.Update(ev); next != nil {
+				c = next
+			}
+		}
+	}
+	return
+}
+
+// Update updates a viewer for an event, and runs the command that it returns.
+func update(v *Viewer, ev tcell.Event) []tcell.Event {
+	return settle(v, v.Update(ev))
+}
+
+// Animation creates an animation of a black and a white frame, which loops
+// forever without a delay.
+func animation() *gif.Helper {
+	palette := color.Palette{color.Black, color.White}
+	frame := func(index uint8) *image.Paletted {
+		m := image.NewPaletted(image.Rect(0, 0, 2, 2), palette)
+		for i := range m.Pix {
+			m.Pix[i] = index
+		}
+		return m
+	}
+	helper, err := gif.NewHelper(&stdgif.GIF{
+		Image:    []*image.Paletted{frame(0), frame(1)},
+		Delay:    []int{0, 0},
+		Disposal: []byte{0, 0},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return &helper
+}
+
+// SameZoom checks if zooms are equal, allowing for rounding errors.
+func sameZoom(a, b view.Zoom) bool {
+	return math.Abs(float64(a-b)) < 0.01
+}
+
+// Key creates an event for a key press of a rune.
+func key(r rune) *tcell.EventKey {
+	return tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)
+}
+
+// Row gets the text of a row of a simulation screen.
+func row(s tcell.SimulationScreen, y int) string {
+	s.Show()
+	cells, width, _ := s.GetContents()
+	var b strings.Builder
+	for _, cell := range cells[y*width : (y+1)*width] {
+		b.WriteRune(cell.Runes[0])
+	}
+	return b.String()
+}
+
+// Uniform creates an image of one color.
+func uniform(width, height int, c color.Color) image.Image {
+	m := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			m.Set(x, y, c)
+		}
+	}
+	return m
+}
+
+// TestViewerOpen checks that the first image would be drawn fitted to the
+// image area, under its title.
+func TestViewerOpen(t *testing.T) {
+	v, s := newTestViewer(uniform(40, 20, color.Black), uniform(40, 20, color.White))
+	v.Draw(s)
+
+	if actual := strings.TrimSpace(row(s, 0)); actual != "[1/2] 0" {
+		t.Errorf(`title = %q, want "[1/2] 0"`, actual)
+	}
+	// NOTE The 40x20 image is 86 cells wide at 100%, so it is fitted to the
+	//      width of the screen
+	if actual := v.zoom; actual >= 100 {
+		t.Errorf(`zoom = %v, want less than 100%%`, actual)
+	}
+	if actual := row(s, 12); strings.Count(actual, "█") != 80 {
+		t.Errorf(`middle row = %q, want 80 blocks`, actual)
+	}
+}
+
+// TestViewerNext checks that the next key would open the next image, and
+// wrap around to the first image.
+func TestViewerNext(t *testing.T) {
+	v, s := newTestViewer(uniform(4, 4, color.Black), uniform(4, 4, color.White))
+
+	update(v, key('n'))
+	v.Draw(s)
+	if actual := strings.TrimSpace(row(s, 0)); actual != "[2/2] 1" {
+		t.Errorf(`title = %q, want "[2/2] 1"`, actual)
+	}
+	update(v, key('n'))
+	if actual := v.browser.Index(); actual != 0 {
+		t.Errorf(`index after the last image = %d, want 0`, actual)
+	}
+}
+
+// TestViewerStaleLoad checks that an image that finishes loading after
+// another image was opened would be ignored.
+func TestViewerStaleLoad(t *testing.T) {
+	first, second := uniform(4, 4, color.Black), uniform(4, 4, color.White)
+	v, _ := newTestViewer(first, second)
+	stale := newEventLoaded(v.generation, "0", loaded{Image: first, title: "0"}, false)
+
+	c := v.Update(key('n'))
+	v.Update(stale)
+	if v.image != first {
+		t.Fatalf(`image before loading = %v, want the first image`, v.image)
+	}
+	settle(v, c)
+	v.Update(stale)
+	if v.image != second {
+		t.Errorf(`image after a stale load = %v, want the second image`, v.image)
+	}
+}
+
+// TestViewerZoom checks that zooming would step the zoom, that the mouse
+// wheel would zoom at the cursor, and that fitting would center the image.
+func TestViewerZoom(t *testing.T) {
+	v, _ := newTestViewer(uniform(40, 20, color.Black))
+	fit := v.zoom
+
+	update(v, key('z'))
+	if actual := v.zoom; actual <= fit {
+		t.Errorf(`zoom after zooming in = %v, want more than %v`, actual, fit)
+	}
+	if actual := v.offset; actual != (image.Point{}) {
+		t.Errorf(`offset after zooming in at the center = %v, want (0,0)`, actual)
+	}
+
+	update(v, tcell.NewEventMouse(10, 13, tcell.WheelUp, tcell.ModNone))
+	// NOTE The left side was zoomed into, so the image moved right
+	if actual := v.offset; actual.X <= 0 {
+		t.Errorf(`offset after zooming at the left = %v, want a positive x`, actual)
+	}
+
+	update(v, key('f'))
+	if actual := v.zoom; !sameZoom(actual, fit) {
+		t.Errorf(`zoom after fitting = %v, want %v`, actual, fit)
+	}
+	if actual := v.offset; actual != (image.Point{}) {
+		t.Errorf(`offset after fitting = %v, want (0,0)`, actual)
+	}
+}
+
+// TestViewerScroll checks that scrolling would move a zoomed image without
+// scrolling past its edges, and that dragging would move it with the cursor.
+func TestViewerScroll(t *testing.T) {
+	v, _ := newTestViewer(uniform(40, 20, color.Black))
+	update(v, key('='))
+
+	update(v, key('l'))
+	if actual := v.offset; actual != (image.Point{1, 0}) {
+		t.Errorf(`offset after scrolling right = %v, want (1,0)`, actual)
+	}
+	for i := 0; i < 10; i++ {
+		update(v, key('L'))
+	}
+	// NOTE The image is 86 cells wide at 100%, 6 more than the screen
+	if actual := v.offset; actual != (image.Point{3, 0}) {
+		t.Errorf(`offset after scrolling past the edge = %v, want (3,0)`, actual)
+	}
+
+	update(v, tcell.NewEventMouse(40, 12, tcell.Button1, tcell.ModNone))
+	update(v, tcell.NewEventMouse(38, 12, tcell.Button1, tcell.ModNone))
+	update(v, tcell.NewEventMouse(38, 12, tcell.ButtonNone, tcell.ModNone))
+	if actual := v.offset; actual != (image.Point{1, 0}) {
+		t.Errorf(`offset after dragging = %v, want (1,0)`, actual)
+	}
+	if r := v.rendered(); r == nil || !r.Covers(v.offset, v.zoomedSize(), v.area()) {
+		t.Errorf(`visible part of the image wasn't rendered after scrolling`)
+	}
+}
+
+// TestViewerThumbnail checks that a thumbnail would be drawn in place of the
+// image until zooming in enlarges it, and that the full image would then be
+// drawn at the same size. The status bar would report the size and the zoom
+// of the full image.
+func TestViewerThumbnail(t *testing.T) {
+	v, _ := newTestViewer(uniform(4, 4, color.Black))
+	full := uniform(400, 400, color.White)
+	decoded := 0
+	v.load = func(filename string, neighbors []string, preview func(image.Image)) loaded {
+		return loaded{Image: uniform(40, 40, color.Black), title: filename, size: image.Point{400, 400}, decode: func() loaded {
+			decoded++
+			return loaded{Image: full, title: filename}
+		}}
+	}
+	settle(v, v.open())
+	if decoded != 0 || v.image.Bounds().Dx() != 40 {
+		t.Fatalf(`image was decoded to fit it to the screen`)
+	}
+	v.showStatus = true
+	if !strings.HasSuffix(v.status(), "thumbnail") {
+		t.Errorf(`status = %q, want it to name the thumbnail`, v.status())
+	}
+	if want := fmt.Sprintf("400x400  zoom %v ", v.zoom/10); !strings.HasPrefix(v.status(), want) {
+		t.Errorf(`status = %q, want it to start with %q`, v.status(), want)
+	}
+
+	for i := 0; v.zoom < 100; i++ {
+		if i == 20 {
+			t.Fatalf(`zoom = %v after zooming in %d times`, v.zoom, i)
+		}
+		c := v.Update(key('z'))
+		if v.zoom < 100 && c != nil {
+			t.Fatalf(`image was decoded at zoom %v`, v.zoom)
+		}
+		size := v.zoomedSize()
+		settle(v, c)
+		if v.image == full {
+			if actual := v.zoomedSize(); actual != size {
+				t.Errorf(`size of the full image = %v, want %v`, actual, size)
+			}
+			break
+		}
+	}
+	if decoded != 1 || v.image != full {
+		t.Errorf(`decoded %d times, want 1`, decoded)
+	}
+	if v.thumbnail {
+		t.Errorf(`thumbnail = true after the full image was drawn`)
+	}
+}
+
+// TestViewerResize checks that resizing would center the image and zoom it to
+// the zoom mode.
+func TestViewerResize(t *testing.T) {
+	v, s := newTestViewer(uniform(40, 20, color.Black))
+	update(v, key('z'))
+	update(v, key('l'))
+
+	s.SetSize(40, 24)
+	update(v, tcell.NewEventResize(40, 24))
+	want := view.FitZoom(image.Point{40, 21}, image.Rect(0, 0, 40, 20))
+	if actual := v.zoom; !sameZoom(actual, want) {
+		t.Errorf(`zoom after resizing = %v, want %v`, actual, want)
+	}
+	if actual := v.offset; actual != (image.Point{}) {
+		t.Errorf(`offset after resizing = %v, want (0,0)`, actual)
+	}
+}
+
+// TestViewerStatusArea checks that the status bar would take the last row of
+// the image area, so that fitted images aren't drawn under it.
+func TestViewerStatusArea(t *testing.T) {
+	v, s := newTestViewer(uniform(4, 100, color.White))
+	update(v, key('i'))
+	if actual := v.zoomedSize().Y; actual != 20 {
+		t.Errorf(`height with the status bar = %d, want 20`, actual)
+	}
+	v.Draw(s)
+	if actual := row(s, 23); !strings.Contains(actual, "4x100") {
+		t.Errorf(`last row = %q, want the status bar`, actual)
+	}
+	if actual := row(s, 22); strings.TrimSpace(actual) == "" {
+		t.Errorf(`last row above the status bar isn't part of the image`)
+	}
+
+	update(v, key('i'))
+	if actual := v.zoomedSize().Y; actual != 21 {
+		t.Errorf(`height without the status bar = %d, want 21`, actual)
+	}
+}
+
+// TestViewerAnimation checks that animations would move to the next frame for
+// their frame events, and that frames of closed animations would be ignored.
+func TestViewerAnimation(t *testing.T) {
+	helper := animation()
+	v, _ := newTestViewer(uniform(2, 2, color.Black))
+	v.load = func(filename string, neighbors []string, preview func(image.Image)) loaded {
+		if filename == "0" {
+			return loaded{Image: helper, title: filename}
+		}
+		return loaded{Image: uniform(2, 2, color.Black), title: filename}
+	}
+	v.browser.Filenames = []string{"0", "1"}
+	frames := settle(v, v.open())
+	if len(frames) != 1 {
+		t.Fatalf(`got %d frame events, want 1`, len(frames))
+	}
+
+	frames = update(v, frames[0])
+	if actual := v.frame; actual != 1 {
+		t.Errorf(`frame = %d, want 1`, actual)
+	}
+	if actual := helper.Index(); actual != 0 {
+		t.Errorf(`frame of the loaded animation = %d, want 0`, actual)
+	}
+	if v.rendered() == nil {
+		t.Errorf(`frame wasn't rendered`)
+	}
+
+	update(v, key('n'))
+	if actual := update(v, frames[0]); len(actual) != 0 {
+		t.Errorf(`got %d frame events for a closed animation, want 0`, len(actual))
+	}
+}
+
+// TestViewerError checks that an image that fails to load would be replaced
+// by its error.
+func TestViewerError(t *testing.T) {
+	v, s := newTestViewer(uniform(4, 4, color.Black), nil)
+	update(v, key('n'))
+	v.Draw(s)
+
+	found := false
+	for y := 0; y < 24; y++ {
+		if strings.Contains(row(s, y), "broken") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf(`error wasn't drawn`)
+	}
+}
+
+// TestViewerErrors checks that files that failed to load would be listed once
+// in the overlay, even if they are opened again.
+func TestViewerErrors(t *testing.T) {
+	v, s := newTestViewer(uniform(4, 4, color.Black), nil)
+	update(v, key('n'))
+	update(v, key('n'))
+	update(v, key('n'))
+	if actual := len(v.errors); actual != 1 {
+		t.Fatalf(`got %d errors after opening the broken file twice, want 1`, actual)
+	}
+	if actual := v.errors[0].filename; actual != "1" {
+		t.Errorf(`filename of the error = %q, want "1"`, actual)
+	}
+
+	update(v, key('E'))
+	v.Draw(s)
+	if actual := row(s, draw.TitleBarPixels+1); !strings.Contains(actual, "broken") {
+		t.Errorf(`overlay row = %q, want the error`, actual)
+	}
+	update(v, key('E'))
+	if actual := v.overlay(); actual != nil {
+		t.Errorf(`overlay after hiding the errors = %v, want nil`, actual)
+	}
+}
+
+// TestViewerSkipBroken checks that broken files would be skipped in the
+// direction that the user was browsing in, and that skipping would stop if
+// every file is broken.
+func TestViewerSkipBroken(t *testing.T) {
+	black := uniform(4, 4, color.Black)
+	v, _ := newTestViewer(black, nil, nil, black)
+	v.options.SkipBroken = true
+
+	update(v, key('n'))
+	if actual := v.browser.Index(); actual != 3 {
+		t.Errorf(`index after skipping forward = %d, want 3`, actual)
+	}
+	update(v, key('N'))
+	if actual := v.browser.Index(); actual != 0 {
+		t.Errorf(`index after skipping backward = %d, want 0`, actual)
+	}
+	if actual := len(v.errors); actual != 2 {
+		t.Errorf(`got %d errors, want 2`, actual)
+	}
+
+	v, _ = newTestViewer(nil, nil)
+	v.options.SkipBroken = true
+	update(v, key('n'))
+	if v.err == nil {
+		t.Errorf(`err = nil after every file was skipped, want an error`)
+	}
+}
+
+// TestViewerSkipBrokenDirection checks that g and n would skip broken files
+// forward, and that G and N would skip them backward, also when browsing
+// wraps around.
+func TestViewerSkipBrokenDirection(t *testing.T) {
+	black := uniform(4, 4, color.Black)
+	for _, tt := range []struct {
+		keys   string
+		images []image.Image
+		want   int
+	}{
+		{"ng", []image.Image{nil, black, black}, 1},
+		{"G", []image.Image{black, black, nil}, 1},
+		{"Gn", []image.Image{nil, black, black}, 1},
+		{"N", []image.Image{black, black, nil}, 1},
+	} {
+		v, _ := newTestViewer(tt.images...)
+		v.options.SkipBroken = true
+		for _, r := range tt.keys {
+			update(v, key(r))
+		}
+		if actual := v.browser.Index(); actual != tt.want {
+			t.Errorf(`%s: index = %d, want %d`, tt.keys, actual, tt.want)
+		}
+	}
+}
+
+// TestViewerMarkBroken checks that the title of a broken file would be
+// marked.
+func TestViewerMarkBroken(t *testing.T) {
+	v, s := newTestViewer(uniform(4, 4, color.Black), nil)
+	v.options.MarkBroken = true
+
+	update(v, key('n'))
+	v.Draw(s)
+	if actual := strings.TrimSpace(row(s, 0)); actual != "[2/2] 1 "+brokenMark {
+		t.Errorf(`title = %q, want "[2/2] 1 %s"`, actual, brokenMark)
+	}
+	update(v, key('n'))
+	v.Draw(s)
+	if actual := strings.TrimSpace(row(s, 0)); actual != "[1/2] 0" {
+		t.Errorf(`title = %q, want "[1/2] 0"`, actual)
+	}
+}
+
+// TestViewerSlideshow checks that the slideshow would move to the next image
+// for its slide events, stop at the end, and ignore slides of earlier runs.
+func TestViewerSlideshow(t *testing.T) {
+	black := uniform(4, 4, color.Black)
+	v, _ := newTestViewer(black, black, black)
+	v.slides.interval = time.Millisecond
+	v.slides.stopAtEnd = true
+
+	slides := update(v, key('s'))
+	if len(slides) != 1 {
+		t.Fatalf(`got %d slide events, want 1`, len(slides))
+	}
+	slides = update(v, slides[0])
+	if actual := v.browser.Index(); actual != 1 {
+		t.Errorf(`index after a slide = %d, want 1`, actual)
+	}
+	update(v, update(v, slides[0])[0])
+	if v.slides.active {
+		t.Errorf(`slideshow didn't stop at the end`)
+	}
+	if actual := v.browser.Index(); actual != 2 {
+		t.Errorf(`index at the end = %d, want 2`, actual)
+	}
+
+	update(v, key('g'))
+	stale := update(v, key('s'))
+	update(v, key('s'))
+	update(v, key('s'))
+	update(v, stale[0])
+	if actual := v.browser.Index(); actual != 0 {
+		t.Errorf(`index after a slide of an earlier run = %d, want 0`, actual)
+	}
+}
+
+// TestViewerSlideshowWraps checks that a slideshow that stops at its end would
+// also show the images before the image that it was started from.
+func TestViewerSlideshowWraps(t *testing.T) {
+	black := uniform(4, 4, color.Black)
+	v, _ := newTestViewer(black, black, black)
+	v.slides.interval = time.Millisecond
+	v.slides.stopAtEnd = true
+	update(v, key('n'))
+
+	slides := update(v, key('s'))
+	var shown []int
+	for i := 0; v.slides.active; i++ {
+		if i == 5 || len(slides) != 1 {
+			t.Fatalf(`slideshow didn't stop after %d slides`, i)
+		}
+		if slides = update(v, slides[0]); v.slides.active {
+			shown = append(shown, v.browser.Index())
+		}
+	}
+	if actual := fmt.Sprint(shown); actual != "[2 0]" {
+		t.Errorf(`indices = %v, want [2 0]`, actual)
+	}
+	if actual := v.browser.Index(); actual != 0 {
+		t.Errorf(`index at the end = %d, want 0`, actual)
+	}
+}
+
+// TestViewerSlideshowShuffled checks that a shuffled slideshow of shuffled
+// files would show each file once, also when the files are shuffled or
+// unshuffled while it runs.
+func TestViewerSlideshowShuffled(t *testing.T) {
+	black := uniform(4, 4, color.Black)
+	filenames := []string{"0", "1", "2", "3", "4", "5"}
+	load := func(filename string, neighbors []string, preview func(image.Image)) loaded {
+		return loaded{Image: black, title: filename}
+	}
+	options := Options{
+		Bindings:           keys.DefaultBindings(),
+		Converter:          conversion.Converter{Mode: conversion.BlockMode},
+		ScrollStep:         10,
+		Slideshow:          time.Millisecond,
+		SlideshowShuffle:   true,
+		SlideshowStopAtEnd: true,
+		Shuffle:            true,
+		Seed:               1,
+	}
+	for _, toggle := range []bool{false, true} {
+		browser := &files.FileBrowser{Filenames: append([]string(nil), filenames...)}
+		v := newViewer(context.Background(), browser, options, load, 80, 24)
+		slides := settle(v, v.open())
+		shown := map[string]int{v.browser.Current(): 1}
+		for len(slides) > 0 {
+			if toggle {
+				// NOTE Toggling opens the file again, which restarts the timer
+				slides = update(v, key('S'))
+			}
+			slides = update(v, slides[0])
+			if v.slides.active {
+				shown[v.browser.Current()]++
+			}
+		}
+		for _, filename := range filenames {
+			if shown[filename] != 1 {
+				t.Errorf(`toggled %v: %q was shown %d times, want 1`, toggle, filename, shown[filename])
+			}
+		}
+	}
+}
+
+// TestViewerSlideshowAnimation checks that the slideshow would wait for an
+// animation to finish a loop before moving to the next image.
+func TestViewerSlideshowAnimation(t *testing.T) {
+	v, _ := newTestViewer(uniform(2, 2, color.Black))
+	v.load = func(filename string, neighbors []string, preview func(image.Image)) loaded {
+		if filename == "0" {
+			return loaded{Image: animation(), title: filename}
+		}
+		return loaded{Image: uniform(2, 2, color.Black), title: filename}
+	}
+	v.browser.Filenames = []string{"0", "1"}
+	v.slides.interval = time.Millisecond
+	frames := settle(v, v.open())
+	slides := update(v, key('s'))
+
+	update(v, slides[0])
+	if actual := v.browser.Index(); actual != 0 {
+		t.Fatalf(`index before the loop finished = %d, want 0`, actual)
+	}
+	frames = update(v, frames[0])
+	if actual := v.browser.Index(); actual != 0 {
+		t.Fatalf(`index on the last frame = %d, want 0`, actual)
+	}
+	update(v, frames[0])
+	if actual := v.browser.Index(); actual != 1 {
+		t.Errorf(`index after the loop finished = %d, want 1`, actual)
+	}
+}
+
+// TestViewerSlideshowStopAnimation checks that stopping the slideshow while
+// an animation waits for its loop to finish would stay on the animation.
+func TestViewerSlideshowStopAnimation(t *testing.T) {
+	v, _ := newTestViewer(uniform(2, 2, color.Black))
+	v.load = func(filename string, neighbors []string, preview func(image.Image)) loaded {
+		if filename == "0" {
+			return loaded{Image: animation(), title: filename}
+		}
+		return loaded{Image: uniform(2, 2, color.Black), title: filename}
+	}
+	v.browser.Filenames = []string{"0", "1"}
+	v.slides.interval = time.Millisecond
+	frames := settle(v, v.open())
+	slides := update(v, key('s'))
+
+	update(v, slides[0])
+	update(v, key('s'))
+	frames = update(v, frames[0])
+	update(v, frames[0])
+	if actual := v.browser.Index(); actual != 0 {
+		t.Errorf(`index after stopping the slideshow = %d, want 0`, actual)
+	}
+}
+
+// TestViewerShuffle checks that shuffling would stay on the current file, and
+// that shuffling again would restore the order of the files.
+func TestViewerShuffle(t *testing.T) {
+	black := uniform(4, 4, color.Black)
+	v, s := newTestViewer(black, black, black, black, black, black)
+	v.options.Seed = 1
+	update(v, key('n'))
+	before := append([]string(nil), v.browser.Filenames...)
+
+	update(v, key('S'))
+	if actual := v.browser.Current(); actual != "1" {
+		t.Errorf(`current file after shuffling = %q, want "1"`, actual)
+	}
+	v.Draw(s)
+	if actual, want := strings.TrimSpace(row(s, 0)), positionTitle(v.browser.Index(), 6, "1"); actual != want {
+		t.Errorf(`title after shuffling = %q, want %q`, actual, want)
+	}
+	if strings.Join(v.browser.Filenames, "") == strings.Join(before, "") {
+		t.Errorf(`files weren't shuffled`)
+	}
+
+	update(v, key('S'))
+	if actual := strings.Join(v.browser.Filenames, ","); actual != strings.Join(before, ",") {
+		t.Errorf(`files after restoring the order = %s, want %s`, actual, strings.Join(before, ","))
+	}
+	if actual := v.browser.Current(); actual != "1" {
+		t.Errorf(`current file after restoring the order = %q, want "1"`, actual)
+	}
+}
+
+// TestViewerRandom checks that the random key would open another file.
+func TestViewerRandom(t *testing.T) {
+	black := uniform(4, 4, color.Black)
+	v, _ := newTestViewer(black, black, black)
+	for i := 0; i < 10; i++ {
+		before := v.browser.Index()
+		update(v, key('x'))
+		if actual := v.browser.Index(); actual == before {
+			t.Errorf(`index after opening a random file = %d, want another file`, actual)
+		}
+	}
+}
+
+// TestViewerTrash checks that the current file would be trashed only once
+// the user confirms it, that the next file would be opened, and that the
+// viewer would quit once no files are left.
+func TestViewerTrash(t *testing.T) {
+	var trashed []string
+	trashFile = func(filename string) error {
+		trashed = append(trashed, filename)
+		return nil
+	}
+	defer func() {
+		trashFile = files.Trash
+	}()
+	black := uniform(4, 4, color.Black)
+	v, s := newTestViewer(black, black)
+
+	update(v, key('d'))
+	update(v, key('n'))
+	if len(trashed) != 0 {
+		t.Fatalf(`trashed %v without confirming, want nothing`, trashed)
+	}
+	if actual := v.browser.Index(); actual != 0 {
+		t.Errorf(`index after cancelling = %d, want 0`, actual)
+	}
+
+	update(v, key('d'))
+	v.Draw(s)
+	if actual := row(s, 0); !strings.HasPrefix(actual, "Move 0 to the trash?") {
+		t.Errorf(`prompt = %q, want the question`, actual)
+	}
+	update(v, key('y'))
+	if strings.Join(trashed, ",") != "0" {
+		t.Errorf(`trashed %v, want [0]`, trashed)
+	}
+	if actual := strings.Join(v.browser.Filenames, ","); actual != "1" {
+		t.Errorf(`files after trashing = %s, want 1`, actual)
+	}
+	v.Draw(s)
+	if actual := strings.TrimSpace(row(s, 0)); actual != "Moved 0 to the trash" {
+		t.Errorf(`message = %q, want "Moved 0 to the trash"`, actual)
+	}
+
+	update(v, key('d'))
+	update(v, key('y'))
+	if !v.Done() {
+		t.Errorf(`viewer wasn't done after trashing every file`)
+	}
+}
+
+// TestViewerTrashShuffledSlideshow checks that trashing a file during a
+// shuffled slideshow would keep the remaining slides on the files that are
+// left, also after shuffling the files.
+func TestViewerTrashShuffledSlideshow(t *testing.T) {
+	trashFile = func(string) error {
+		return nil
+	}
+	defer func() {
+		trashFile = files.Trash
+	}()
+	black := uniform(4, 4, color.Black)
+	v, _ := newTestViewer(black, black, black, black)
+	v.slides.interval = time.Millisecond
+	v.slides.shuffle = true
+	v.slides.stopAtEnd = true
+
+	update(v, key('s'))
+	update(v, key('d'))
+	update(v, key('y'))
+	slides := update(v, key('S'))
+	shown := map[string]bool{}
+	for len(slides) > 0 {
+		slides = update(v, slides[0])
+		shown[v.browser.Current()] = true
+	}
+	if shown["0"] {
+		t.Errorf(`trashed file was shown`)
+	}
+	for _, filename := range []string{"2", "3"} {
+		if !shown[filename] {
+			t.Errorf(`%q wasn't shown`, filename)
+		}
+	}
+}
+
+// TestViewerTrashBroken checks that a broken file that was trashed wouldn't
+// be listed with the errors anymore.
+func TestViewerTrashBroken(t *testing.T) {
+	trashFile = func(string) error {
+		return nil
+	}
+	defer func() {
+		trashFile = files.Trash
+	}()
+	v, _ := newTestViewer(nil, uniform(4, 4, color.Black))
+
+	update(v, key('d'))
+	update(v, key('y'))
+	if len(v.errors) != 0 {
+		t.Errorf(`errors after trashing the broken file = %v, want none`, v.errors)
+	}
+}
+
+// TestViewerMoveEmpty checks that an empty directory wouldn't move the
+// current file to the working directory.
+func TestViewerMoveEmpty(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "a.png")
+	if err := os.WriteFile(filename, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	v, _ := newTestViewer(uniform(4, 4, color.Black))
+	v.load = func(filename string, neighbors []string, preview func(image.Image)) loaded {
+		return loaded{Image: uniform(4, 4, color.Black), title: filepath.Base(filename)}
+	}
+	v.browser.Filenames = []string{filename}
+	settle(v, v.open())
+
+	for _, r := range "v  " {
+		update(v, key(r))
+	}
+	update(v, tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	if _, err := os.Stat(filename); err != nil {
+		t.Errorf(`file after moving it to an empty directory: %v`, err)
+	}
+	if actual := v.browser.Current(); actual != filename {
+		t.Errorf(`current file = %q, want %q`, actual, filename)
+	}
+	if v.prompt.message == "" {
+		t.Errorf(`no error was shown`)
+	}
+}
+
+// TestViewerRename checks that renaming would rename the current file on disk
+// and browse it by its new name.
+func TestViewerRename(t *testing.T) {
+	dir := t.TempDir()
+	v, _ := newTestViewer(uniform(4, 4, color.Black))
+	v.load = func(filename string, neighbors []string, preview func(image.Image)) loaded {
+		return loaded{Image: uniform(4, 4, color.Black), title: filepath.Base(filename)}
+	}
+	v.browser.Filenames = nil
+	for _, name := range []string{"a.png", "b.png"} {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		v.browser.Filenames = append(v.browser.Filenames, filename)
+	}
+	settle(v, v.open())
+
+	update(v, tcell.NewEventKey(tcell.KeyF2, 0, tcell.ModNone))
+	update(v, tcell.NewEventKey(tcell.KeyCtrlU, 0, tcell.ModNone))
+	for _, r := range "c.png" {
+		update(v, key(r))
+	}
+	update(v, tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+
+	renamed := filepath.Join(dir, "c.png")
+	if _, err := os.Stat(renamed); err != nil {
+		t.Errorf(`renamed file: %v`, err)
+	}
+	if actual := v.browser.Current(); actual != renamed {
+		t.Errorf(`current file = %q, want %q`, actual, renamed)
+	}
+	if actual := v.title; !strings.Contains(actual, "c.png") {
+		t.Errorf(`title = %q, want the new name`, actual)
+	}
+
+	update(v, tcell.NewEventKey(tcell.KeyF2, 0, tcell.ModNone))
+	update(v, tcell.NewEventKey(tcell.KeyCtrlU, 0, tcell.ModNone))
+	for _, r := range "b.png" {
+		update(v, key(r))
+	}
+	update(v, tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	if actual := v.browser.Current(); actual != renamed {
+		t.Errorf(`current file after renaming over another file = %q, want %q`, actual, renamed)
+	}
+	if actual := v.prompt.message; !strings.Contains(actual, "already exists") {
+		t.Errorf(`message = %q, want an error`, actual)
+	}
+}
+
+// TestViewerMarks checks that marks and tags would be shown in the title, and
+// that the marked files would be selected in the order that they were
+// browsed in before they were shuffled.
+func TestViewerMarks(t *testing.T) {
+	black := uniform(4, 4, color.Black)
+	v, s := newTestViewer(black, black, black, black)
+
+	update(v, key(' '))
+	update(v, key('t'))
+	for _, r := range "keep" {
+		update(v, key(r))
+	}
+	update(v, tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	v.Draw(s)
+	if actual := strings.TrimSpace(row(s, 0)); actual != "[1/4] 0 * #keep" {
+		t.Errorf(`title = %q, want "[1/4] 0 * #keep"`, actual)
+	}
+
+	update(v, key('G'))
+	update(v, key(' '))
+	update(v, key('S'))
+	if actual := strings.Join(v.selected(), ","); actual != "0,3" {
+		t.Errorf(`selected files = %s, want 0,3`, actual)
+	}
+	v.options.PrintTags = []string{"keep"}
+	if actual := strings.Join(v.selected(), ","); actual != "0" {
+		t.Errorf(`files with the tag = %s, want 0`, actual)
+	}
+}
+
+// TestViewerQuit checks that the quit key would finish the viewer.
+func TestViewerQuit(t *testing.T) {
+	v, _ := newTestViewer(uniform(4, 4, color.Black))
+	v.Update(tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone))
+	if !v.Done() {
+		t.Errorf(`viewer wasn't done after quitting`)
+	}
+}
@@ -0,0 +1,180 @@
+// Package config loads the user's configuration file.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/spenserblack/termage/internal/keys"
+)
+
+// Config is the contents of the configuration file.
+type Config struct {
+	// Mode is the render mode: alpha, block or ascii.
+	Mode string `toml:"mode"`
+	// Colors limits the colors images are drawn with: truecolor, 256, 16 or
+	// none.
+	Colors string `toml:"colors"`
+	// Background is the background color of images, as a W3C color name or a
+	// hex color like "#1d1f21". An empty string is the terminal background.
+	Background string `toml:"background"`
+	// Sort is the order files are browsed in: none, name, natural, mtime or
+	// size. With none, directories are browsed by name and files given on the
+	// command line are browsed in the order they were given.
+	Sort string `toml:"sort"`
+	// Zoom is the zoom that images are opened at: "fit", "fit-width",
+	// "fit-height", "1:1" or a percentage.
+	Zoom string `toml:"zoom"`
+	// Filter is the filter that images are resampled with when they are
+	// zoomed: nearest, box, linear, catmull-rom or lanczos.
+	Filter string `toml:"filter"`
+	// ScrollStep is the percentage of the image that fast scrolling moves.
+	ScrollStep int `toml:"scroll-step"`
+	// Keys maps action names to the keys that trigger them.
+	Keys map[keys.Action]KeyList `toml:"keys"`
+}
+
+// EnvPrefix is the prefix of environment variables that override the config
+// file.
+const EnvPrefix = "TERMAGE_"
+
+// Default gets the default configuration.
+func Default() Config {
+	return Config{
+		Mode:       "alpha",
+		Colors:     "truecolor",
+		Sort:       "none",
+		Zoom:       "fit",
+		Filter:     "linear",
+		ScrollStep: 10,
+	}
+}
+
+// KeyList is a list of keys, which can be written as a single string or as an
+// array of strings.
+type KeyList []string
+
+// For mocking functions.
+var (
+	getenv      = os.Getenv
+	userHomeDir = os.UserHomeDir
+)
+
+// Path gets the path of the configuration file, which is
+// $XDG_CONFIG_HOME/termage/config.toml.
+func Path() (string, error) {
+	configHome := getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := userHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("Couldn't find config directory: %w", err)
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "termage", "config.toml"), nil
+}
+
+// Load loads a configuration file over the default configuration. A missing
+// file is not an error.
+func Load(path string) (config Config, err error) {
+	config = Default()
+	_, err = toml.DecodeFile(path, &config)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Default(), nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("Couldn't load config %q: %w", path, err)
+	}
+	return config, nil
+}
+
+// LoadEnv overrides the configuration with TERMAGE_* environment variables,
+// like TERMAGE_MODE or TERMAGE_SCROLL_STEP.
+func (config *Config) LoadEnv() error {
+	for name, field := range map[string]*string{
+		"MODE":       &config.Mode,
+		"COLORS":     &config.Colors,
+		"BACKGROUND": &config.Background,
+		"SORT":       &config.Sort,
+		"ZOOM":       &config.Zoom,
+		"FILTER":     &config.Filter,
+	} {
+		if v := getenv(EnvPrefix + name); v != "" {
+			*field = v
+		}
+	}
+	if v := getenv(EnvPrefix + "SCROLL_STEP"); v != "" {
+		step, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("Invalid %sSCROLL_STEP %q: %w", EnvPrefix, v, err)
+		}
+		config.ScrollStep = step
+	}
+	return nil
+}
+
+// LoadDefault loads the configuration file at the default path.
+func LoadDefault() (Config, error) {
+	path, err := Path()
+	if err != nil {
+		return Config{}, err
+	}
+	return Load(path)
+}
+
+// Bindings gets the default key bindings with the configured keys applied. A
+// key that is configured for more than one action is an error.
+func (config Config) Bindings() (keys.Bindings, error) {
+	for action := range config.Keys {
+		if !keys.IsAction(action) {
+			return nil, fmt.Errorf("Unknown action %q", action)
+		}
+	}
+	bindings := keys.DefaultBindings()
+	configured := make(map[keys.Key]keys.Action)
+	// NOTE Actions are bound in the order of the registry, so that the same
+	//      config always has the same bindings and errors
+	for _, info := range keys.Registry {
+		keyList, ok := config.Keys[info.Action]
+		if !ok {
+			continue
+		}
+		if err := bindings.Rebind(info.Action, keyList); err != nil {
+			return nil, err
+		}
+		for _, k := range bindings.Keys(info.Action) {
+			if other, ok := configured[k]; ok {
+				return nil, fmt.Errorf("Key %s is bound to both %q and %q", k, other, info.Action)
+			}
+			configured[k] = info.Action
+		}
+	}
+	return bindings, nil
+}
+
+// UnmarshalTOML allows a KeyList to be either a string or an array.
+func (list *KeyList) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case string:
+		*list = KeyList{v}
+	case []interface{}:
+		keyList := make(KeyList, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("Key %v is not a string", item)
+			}
+			keyList = append(keyList, s)
+		}
+		*list = keyList
+	default:
+		return fmt.Errorf("Keys must be a string or an array of strings, got %v", data)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spenserblack/termage/internal/keys"
+)
+
+// WriteConfig writes a config file to a temporary directory.
+func writeConfig(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		panic(err)
+	}
+	return path
+}
+
+// TestPath checks that the config file would be in XDG_CONFIG_HOME.
+func TestPath(t *testing.T) {
+	getenv = func(key string) string {
+		if key == "XDG_CONFIG_HOME" {
+			return filepath.FromSlash("/tmp/config")
+		}
+		return ""
+	}
+	defer func() {
+		getenv = os.Getenv
+	}()
+
+	path, err := Path()
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if want := filepath.FromSlash("/tmp/config/termage/config.toml"); path != want {
+		t.Errorf(`path = %q, want %q`, path, want)
+	}
+}
+
+// TestMissingConfig checks that a missing config file is not an error.
+func TestMissingConfig(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "nope.toml")); err != nil {
+		t.Errorf(`err = %v, want nil`, err)
+	}
+}
+
+// TestLoadKeys checks that keys can be configured as strings or arrays.
+func TestLoadKeys(t *testing.T) {
+	path := writeConfig(t, `
+[keys]
+next = ["n", "Right"]
+zoom-in = "Ctrl+Up"
+`)
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	bindings, err := c.Bindings()
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	if actual := bindings.Keys(keys.Next); len(actual) != 2 {
+		t.Errorf(`Keys(Next) = %v, want 2 keys`, actual)
+	}
+	if actual := bindings.Keys(keys.ZoomIn); len(actual) != 1 || actual[0].String() != "Ctrl+Up" {
+		t.Errorf(`Keys(ZoomIn) = %v, want [Ctrl+Up]`, actual)
+	}
+	if actual := bindings.Keys(keys.Previous); len(actual) != 1 || actual[0].String() != "N" {
+		t.Errorf(`Keys(Previous) = %v, want default [N]`, actual)
+	}
+}
+
+// TestBadKeys checks that invalid keys, and keys that are bound to more
+// than one action, are errors.
+func TestBadKeys(t *testing.T) {
+	for _, contents := range []string{
+		"[keys]\nnext = 1",
+		"[keys]\nnext = [1]",
+		"[keys]\nfly = \"f\"",
+		"[keys]\nnext = \"x\"\nprevious = [\"p\", \"x\"]",
+	} {
+		c, err := Load(writeConfig(t, contents))
+		if err == nil {
+			_, err = c.Bindings()
+		}
+		if err == nil {
+			t.Errorf(`%q err = nil`, contents)
+		}
+	}
+}
+
+// TestLoadSettings checks that settings in the config file would override the
+// defaults.
+func TestLoadSettings(t *testing.T) {
+	path := writeConfig(t, `
+mode = "block"
+scroll-step = 25
+`)
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	if c.Mode != "block" {
+		t.Errorf(`Mode = %q, want "block"`, c.Mode)
+	}
+	if c.ScrollStep != 25 {
+		t.Errorf(`ScrollStep = %d, want 25`, c.ScrollStep)
+	}
+	if want := Default().Colors; c.Colors != want {
+		t.Errorf(`Colors = %q, want default %q`, c.Colors, want)
+	}
+}
+
+// TestLoadEnv checks that environment variables would override the config.
+func TestLoadEnv(t *testing.T) {
+	env := map[string]string{
+		"TERMAGE_MODE":        "ascii",
+		"TERMAGE_SCROLL_STEP": "5",
+	}
+	getenv = func(key string) string {
+		return env[key]
+	}
+	defer func() {
+		getenv = os.Getenv
+	}()
+	c := Default()
+	c.Colors = "256"
+
+	if err := c.LoadEnv(); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	if c.Mode != "ascii" {
+		t.Errorf(`Mode = %q, want "ascii"`, c.Mode)
+	}
+	if c.ScrollStep != 5 {
+		t.Errorf(`ScrollStep = %d, want 5`, c.ScrollStep)
+	}
+	if c.Colors != "256" {
+		t.Errorf(`Colors = %q, want "256"`, c.Colors)
+	}
+
+	env["TERMAGE_SCROLL_STEP"] = "lots"
+	if err := c.LoadEnv(); err == nil {
+		t.Errorf(`invalid scroll step err = nil`)
+	}
+}
@@ -50,23 +50,7 @@ func RGBRuneFromColor(c color.Color) RGBRune {
 
 // RGBRunesFromImage creates a slice of RGBRunes from an Image.
 func RGBRunesFromImage(i image.Image) RGBRunes {
-	bounds := i.Bounds()
-	width := bounds.Max.X
-	height := bounds.Max.Y
-
-	rgbRunes := make([]RGBRune, 0, width*height)
-
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			rgbRunes = append(rgbRunes, RGBRuneFromColor(i.At(x, y)))
-		}
-	}
-
-	return RGBRunes{
-		rgbRunes,
-		width,
-		height,
-	}
+	return Converter{}.FromImage(i)
 }
 
 // At gets the RGBRune at a point.
@@ -0,0 +1,104 @@
+package conversion
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Mode decides which runes pixels are converted to.
+type Mode int
+
+const (
+	// AlphaMode uses shading runes for the alpha level of each pixel.
+	AlphaMode Mode = iota
+	// BlockMode uses full blocks, blending transparent pixels with the
+	// background.
+	BlockMode
+	// ASCIIMode uses ASCII characters for the brightness of each pixel.
+	ASCIIMode
+)
+
+// ASCIIChars contains runes representing brightness levels, from darkest
+// (lowest index) to brightest (highest index).
+var ASCIIChars = [...]rune{' ', '.', ':', '-', '=', '+', '*', '#', '%', '@'}
+
+// Names of modes, as used in options.
+var modeNames = map[Mode]string{
+	AlphaMode: "alpha",
+	BlockMode: "block",
+	ASCIIMode: "ascii",
+}
+
+// ParseMode parses the name of a mode.
+func ParseMode(name string) (Mode, error) {
+	for mode, modeName := range modeNames {
+		if name == modeName {
+			return mode, nil
+		}
+	}
+	return AlphaMode, fmt.Errorf("Unknown render mode %q, want alpha, block or ascii", name)
+}
+
+// String gets the name of a mode.
+func (mode Mode) String() string {
+	return modeNames[mode]
+}
+
+// Converter converts images to RGBRunes.
+type Converter struct {
+	Mode Mode
+	// Background is the color that transparent pixels are blended with in
+	// BlockMode. Nil is black.
+	Background color.Color
+}
+
+// FromColor converts a color into an RGBRune.
+func (c Converter) FromColor(col color.Color) RGBRune {
+	switch c.Mode {
+	case BlockMode:
+		r, g, b := blend(col, c.Background)
+		return RGBRune{r, g, b, AlphaChars[len(AlphaChars)-1]}
+	case ASCIIMode:
+		r, g, b, a := col.RGBA()
+		if a == 0 {
+			return RGBRune{0, 0, 0, ASCIIChars[0]}
+		}
+		// NOTE Un-premultiplies to get the brightness of the color itself
+		r, g, b = r*0xFFFF/a, g*0xFFFF/a, b*0xFFFF/a
+		luminance := (299*r + 587*g + 114*b) / 1000
+		index := int(luminance) * len(ASCIIChars) / 0x10000
+		return RGBRune{r, g, b, ASCIIChars[index]}
+	default:
+		return RGBRuneFromColor(col)
+	}
+}
+
+// FromImage creates RGBRunes from an Image.
+func (c Converter) FromImage(i image.Image) RGBRunes {
+	bounds := i.Bounds()
+	width := bounds.Max.X
+	height := bounds.Max.Y
+	rgbRunes := make([]RGBRune, 0, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			rgbRunes = append(rgbRunes, c.FromColor(i.At(x, y)))
+		}
+	}
+	return RGBRunes{
+		rgbRunes,
+		width,
+		height,
+	}
+}
+
+// Blend blends a premultiplied color over a background color.
+func blend(fg, bg color.Color) (r, g, b uint32) {
+	r, g, b, a := fg.RGBA()
+	if bg == nil {
+		return
+	}
+	bgR, bgG, bgB, _ := bg.RGBA()
+	remaining := 0xFFFF - a
+	return r + bgR*remaining/0xFFFF, g + bgG*remaining/0xFFFF, b + bgB*remaining/0xFFFF
+}
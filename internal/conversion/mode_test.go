@@ -0,0 +1,56 @@
+package conversion
+
+import (
+	"image/color"
+	"testing"
+)
+
+// TestParseMode checks that modes can be parsed from their names.
+func TestParseMode(t *testing.T) {
+	for _, mode := range []Mode{AlphaMode, BlockMode, ASCIIMode} {
+		actual, err := ParseMode(mode.String())
+		if err != nil {
+			t.Fatalf(`err = %v, want nil`, err)
+		}
+		if actual != mode {
+			t.Errorf(`ParseMode(%q) = %v, want %v`, mode.String(), actual, mode)
+		}
+	}
+	if _, err := ParseMode("sixel"); err == nil {
+		t.Errorf(`err = nil`)
+	}
+}
+
+// TestBlockMode checks that transparent pixels would be blended with the
+// background in block mode.
+func TestBlockMode(t *testing.T) {
+	c := Converter{BlockMode, color.White}
+
+	halfRed := color.NRGBA{0xFF, 0, 0, 0x80}
+	actual := c.FromColor(halfRed)
+
+	if actual.Rune != '█' {
+		t.Errorf(`rune = %q, want '█'`, actual.Rune)
+	}
+	if actual.R != 0xFFFF {
+		t.Errorf(`red = %x, want ffff`, actual.R)
+	}
+	if actual.G < 0x7000 || actual.G > 0x8000 {
+		t.Errorf(`green = %x, want about half`, actual.G)
+	}
+}
+
+// TestASCIIMode checks that brightness would be converted to ASCII characters.
+func TestASCIIMode(t *testing.T) {
+	c := Converter{Mode: ASCIIMode}
+	for col, want := range map[color.Color]rune{
+		color.Black:       ' ',
+		color.White:       '@',
+		color.Transparent: ' ',
+		color.Gray{0x80}:  '+',
+	} {
+		if actual := c.FromColor(col).Rune; actual != want {
+			t.Errorf(`rune for %v = %q, want %q`, col, actual, want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package draw
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/spenserblack/termage/internal/conversion"
+)
+
+// ANSIReset resets the colors of the text after it.
+const ansiReset = "\x1b[0m"
+
+// WriteANSI writes an image as lines of text, colored with ANSI escape codes
+// for the palette of the style.
+func WriteANSI(w io.Writer, rgbRunes conversion.RGBRunes, style Style) error {
+	out := bufio.NewWriter(w)
+	for y := 0; y < rgbRunes.Height(); y++ {
+		var last tcell.Style
+		for x := 0; x < rgbRunes.Width(); x++ {
+			rgbRune := rgbRunes.At(x, y)
+			// NOTE Escape codes are only written when the colors change
+			if cellStyle := style.cellStyle(rgbRune); x == 0 || cellStyle != last {
+				out.WriteString(ansiReset)
+				out.WriteString(sgr(cellStyle))
+				last = cellStyle
+			}
+			out.WriteRune(rgbRune.Rune)
+		}
+		out.WriteString(ansiReset + "\n")
+	}
+	return out.Flush()
+}
+
+// SGR gets the escape code that sets the foreground and background colors of
+// a cell style.
+func sgr(style tcell.Style) string {
+	fg, bg, _ := style.Decompose()
+	codes := ""
+	if code := ansiColor(fg, 38, 30, 90); code != "" {
+		codes += code
+	}
+	if code := ansiColor(bg, 48, 40, 100); code != "" {
+		if codes != "" {
+			codes += ";"
+		}
+		codes += code
+	}
+	if codes == "" {
+		return ""
+	}
+	return "\x1b[" + codes + "m"
+}
+
+// ANSIColor gets the parameters of an SGR escape code for a color. Extended
+// is the code of 256 and 24-bit colors, and basic and bright are the codes of
+// the first and last 8 of the 16 basic colors. The default color has no
+// parameters.
+func ansiColor(c tcell.Color, extended, basic, bright int) string {
+	switch {
+	case c == tcell.ColorDefault:
+		return ""
+	case c.IsRGB():
+		r, g, b := c.RGB()
+		return fmt.Sprintf("%d;2;%d;%d;%d", extended, r, g, b)
+	}
+	index := int(c - tcell.ColorValid)
+	switch {
+	case index < 8:
+		return fmt.Sprint(basic + index)
+	case index < 16:
+		return fmt.Sprint(bright + index - 8)
+	}
+	return fmt.Sprintf("%d;5;%d", extended, index)
+}
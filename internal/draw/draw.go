@@ -1,6 +1,7 @@
 package draw
 
 import (
+	"fmt"
 	"image"
 
 	"github.com/gdamore/tcell/v2"
@@ -35,6 +36,103 @@ func Title(s tcell.Screen, title string) {
 	s.Show()
 }
 
+// Runes of the buttons in the title bar that go to the previous and next
+// images.
+const (
+	PreviousButton rune = '◀'
+	NextButton     rune = '▶'
+)
+
+// TitleButtons draws buttons to go to the previous and next images on the
+// edges of the title bar.
+func TitleButtons(s tcell.Screen) {
+	width, _ := s.Size()
+	row := TitleBarPixels / 2
+	s.SetContent(0, row, PreviousButton, nil, tcell.StyleDefault)
+	s.SetContent(width-1, row, NextButton, nil, tcell.StyleDefault)
+	s.Show()
+}
+
+// Status draws a status line in reverse video on the last row of the screen,
+// over the bottom of the image.
+func Status(s tcell.Screen, status string) {
+	width, height := s.Size()
+	style := tcell.StyleDefault.Reverse(true)
+	runes := []rune(status)
+	for x := 0; x < width; x++ {
+		r := ' '
+		if x < len(runes) {
+			r = runes[x]
+		}
+		s.SetContent(x, height-1, r, nil, style)
+	}
+	s.Show()
+}
+
+// Overlay draws lines in reverse video in the top-left corner of the image
+// area, over the image. Lines are padded to the same width.
+func Overlay(s tcell.Screen, lines []string) {
+	width := 0
+	for _, line := range lines {
+		if n := len([]rune(line)); n > width {
+			width = n
+		}
+	}
+	style := tcell.StyleDefault.Reverse(true)
+	for i, line := range lines {
+		padded := fmt.Sprintf(" %-*s ", width, line)
+		drawString(s, 0, TitleBarPixels+i, padded, style)
+	}
+	s.Show()
+}
+
+// TitleButtonAt checks if a point on a screen of a width is on a title bar
+// button. It returns -1 for the previous button, 1 for the next button, and 0
+// for neither. The left and right quarters of the title bar are the buttons.
+func TitleButtonAt(width, x, y int) int {
+	if y < 0 || y >= TitleBarPixels {
+		return 0
+	}
+	switch {
+	case x < width/4:
+		return -1
+	case x >= width-width/4:
+		return 1
+	}
+	return 0
+}
+
+// Search draws a search prompt in place of the title. The query is drawn on
+// the first row, and the selected match below it.
+func Search(s tcell.Screen, query string, match string, selected, total int) {
+	width, _ := s.Size()
+	clearRow(s, 0, TitleBarPixels-1, width)
+	drawString(s, 0, 0, "/"+query, tcell.StyleDefault)
+	s.ShowCursor(len([]rune(query))+1, 0)
+	status := "no matches"
+	if total > 0 {
+		status = fmt.Sprintf("[%d/%d] %s", selected+1, total, match)
+	}
+	drawString(s, 0, 1, status, tcell.StyleDefault.Reverse(total > 0))
+	s.Show()
+}
+
+// Prompt draws a prompt in place of the title, like a question and the answer
+// that is being typed, with a hint on the row below it. The cursor is shown
+// after the answer if typing is true.
+func Prompt(s tcell.Screen, question string, answer string, typing bool, hint string) {
+	width, _ := s.Size()
+	clearRow(s, 0, TitleBarPixels-1, width)
+	line := question
+	if typing {
+		line += " " + answer
+		s.ShowCursor(len([]rune(line)), 0)
+	}
+	drawString(s, 0, 0, line, tcell.StyleDefault)
+	drawString(s, 0, 1, hint, tcell.StyleDefault.Dim(true))
+	s.Show()
+}
+
 // Image draws an image to a screen.
 //
 // Center is the center of the image relative to the screen's center, with
@@ -65,6 +163,51 @@ func Image(s tcell.Screen, rgbRunes conversion.RGBRunes, center image.Point) {
 	s.Show()
 }
 
+// StyledImageIn draws an image centered in a region of a screen, clearing the
+// rest of the region. Parts of the image outside of the region aren't drawn.
+// The screen isn't shown, since it belongs to the caller.
+func StyledImageIn(s tcell.Screen, rgbRunes conversion.RGBRunes, region image.Rectangle, style Style) {
+	width, height := rgbRunes.Width(), rgbRunes.Height()
+	left := region.Min.X + (region.Dx()-width)/2
+	top := region.Min.Y + (region.Dy()-height)/2
+	for y := region.Min.Y; y < region.Max.Y; y++ {
+		for x := region.Min.X; x < region.Max.X; x++ {
+			if x < left || x >= left+width || y < top || y >= top+height {
+				s.SetContent(x, y, ' ', nil, tcell.StyleDefault)
+				continue
+			}
+			rgbRune := rgbRunes.At(x-left, y-top)
+			s.SetContent(x, y, rgbRune.Rune, nil, style.cellStyle(rgbRune))
+		}
+	}
+}
+
+// Canvas is anything images can be drawn on, like a screen or a view of a
+// part of a screen.
+type Canvas interface {
+	SetContent(x, y int, mainc rune, combc []rune, style tcell.Style)
+	Size() (width, height int)
+}
+
+// ImageAt draws an image on a canvas with its top-left corner at a point,
+// clearing the rest of the canvas. Parts of the image outside of the canvas
+// aren't drawn.
+func ImageAt(c Canvas, rgbRunes conversion.RGBRunes, topLeft image.Point, style Style) {
+	width, height := rgbRunes.Width(), rgbRunes.Height()
+	canvasWidth, canvasHeight := c.Size()
+	for y := 0; y < canvasHeight; y++ {
+		for x := 0; x < canvasWidth; x++ {
+			imageX, imageY := x-topLeft.X, y-topLeft.Y
+			if imageX < 0 || imageX >= width || imageY < 0 || imageY >= height {
+				c.SetContent(x, y, ' ', nil, tcell.StyleDefault)
+				continue
+			}
+			rgbRune := rgbRunes.At(imageX, imageY)
+			c.SetContent(x, y, rgbRune.Rune, nil, style.cellStyle(rgbRune))
+		}
+	}
+}
+
 // Error draws an error to the screen.
 //
 // An error should be drawn if an image *cannot* be drawn. An error should not
@@ -97,6 +240,13 @@ func Error(s tcell.Screen, err error) {
 	}
 }
 
+// DrawString draws a string on a single row, starting at a column.
+func drawString(s tcell.Screen, x, y int, str string, style tcell.Style) {
+	for i, r := range []rune(str) {
+		s.SetContent(x+i, y, r, nil, style)
+	}
+}
+
 // ClearRow clears a single row of a Screen.
 func ClearRow(s tcell.Screen, start, end int) {
 	width, _ := s.Size()
@@ -1,12 +1,15 @@
 package draw
 
 import (
+	"bytes"
 	"errors"
 	"image"
+	"image/color"
 	_ "image/png" // Register PNGs for tests
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/gdamore/tcell/v2"
@@ -504,3 +507,176 @@ func getResource(resourceName string) string {
 	dir := thisDirOrPanic()
 	return filepath.Join(dir, "..", "..", "_resources", "tests", "internal", "draw", resourceName)
 }
+
+// TestTitleButtons checks that the previous and next buttons would be drawn on
+// the edges of the title bar.
+func TestTitleButtons(t *testing.T) {
+	s := NewMockScreen(8, 6)
+	TitleButtons(s)
+
+	row := s.pixels[TitleBarPixels/2]
+	if actual := row[0].mainc; actual != PreviousButton {
+		t.Errorf(`left edge = %q, want %q`, actual, PreviousButton)
+	}
+	if actual := row[7].mainc; actual != NextButton {
+		t.Errorf(`right edge = %q, want %q`, actual, NextButton)
+	}
+}
+
+// TestTitleButtonAt checks that clicks on the edges of the title bar would be
+// detected as buttons.
+func TestTitleButtonAt(t *testing.T) {
+	for _, tt := range []struct {
+		x, y, want int
+	}{
+		{0, 0, -1},
+		{1, 2, -1},
+		{4, 1, 0},
+		{7, 0, 1},
+		{0, TitleBarPixels, 0},
+	} {
+		if actual := TitleButtonAt(8, tt.x, tt.y); actual != tt.want {
+			t.Errorf(`TitleButtonAt(%d, %d) = %d, want %d`, tt.x, tt.y, actual, tt.want)
+		}
+	}
+}
+
+// TestStatus checks that the status would be drawn in reverse on the last row,
+// padded to the width of the screen.
+func TestStatus(t *testing.T) {
+	s := NewMockScreen(6, 6)
+	Status(s, "4x4")
+
+	row := s.pixels[5]
+	for x, want := range []rune("4x4   ") {
+		if actual := row[x].mainc; actual != want {
+			t.Errorf(`status column %d = %q, want %q`, x, actual, want)
+		}
+		if _, _, attrs := row[x].style.Decompose(); attrs&tcell.AttrReverse == 0 {
+			t.Errorf(`status column %d is not reversed`, x)
+		}
+	}
+}
+
+// TestOverlay checks that the lines would be drawn below the title bar,
+// padded to the same width.
+func TestOverlay(t *testing.T) {
+	s := NewMockScreen(8, 8)
+	Overlay(s, []string{"ab", "abcd"})
+
+	for i, want := range []string{" ab   ", " abcd "} {
+		row := s.pixels[TitleBarPixels+i]
+		for x, r := range want {
+			if actual := row[x].mainc; actual != r {
+				t.Errorf(`overlay (%d, %d) = %q, want %q`, x, i, actual, r)
+			}
+		}
+	}
+}
+
+// TestPrompt checks that a prompt would replace the title, with the answer
+// after the question and the hint below it.
+func TestPrompt(t *testing.T) {
+	s := NewMockScreen(20, 8)
+	Title(s, "title")
+	Prompt(s, "Rename to:", "a.png", true, "Esc to cancel")
+
+	for y, want := range []string{"Rename to: a.png    ", "Esc to cancel       ", "                    "} {
+		var b strings.Builder
+		for _, pixel := range s.pixels[y] {
+			b.WriteRune(pixel.mainc)
+		}
+		if actual := b.String(); actual != want {
+			t.Errorf(`row %d = %q, want %q`, y, actual, want)
+		}
+	}
+}
+
+// TestStyledImageIn checks that an image would be centered in a region, and
+// that the rest of the region would be cleared without drawing outside of it.
+func TestStyledImageIn(t *testing.T) {
+	s := NewMockScreen(6, 6)
+	for y := range s.pixels {
+		for x := range s.pixels[y] {
+			s.pixels[y][x].mainc = 'x'
+		}
+	}
+	m := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	m.Set(0, 0, color.Black)
+	m.Set(1, 0, color.Black)
+	rgbRunes := conversion.RGBRunesFromImage(m)
+	region := image.Rect(1, 1, 5, 4)
+
+	StyledImageIn(s, rgbRunes, region, Style{})
+
+	if actual := s.pixels[0][0].mainc; actual != 'x' {
+		t.Errorf(`rune outside of region = %q, want 'x'`, actual)
+	}
+	if actual := s.pixels[1][1].mainc; actual != ' ' {
+		t.Errorf(`rune @ 1, 1 = %q, want ' '`, actual)
+	}
+	for _, x := range []int{2, 3} {
+		if actual := s.pixels[2][x].mainc; actual != '█' {
+			t.Errorf(`rune @ %d, 2 = %q, want '█'`, x, actual)
+		}
+	}
+	if actual := s.pixels[2][5].mainc; actual != 'x' {
+		t.Errorf(`rune right of region = %q, want 'x'`, actual)
+	}
+}
+
+// TestImageAt checks that an image would be drawn at a point of a canvas,
+// clipped to the canvas, and that the rest of the canvas would be cleared.
+func TestImageAt(t *testing.T) {
+	s := NewMockScreen(4, 3)
+	for y := range s.pixels {
+		for x := range s.pixels[y] {
+			s.pixels[y][x].mainc = 'x'
+		}
+	}
+	m := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	for x := 0; x < 3; x++ {
+		m.Set(x, 0, color.Black)
+	}
+	rgbRunes := conversion.RGBRunesFromImage(m)
+
+	ImageAt(s, rgbRunes, image.Point{2, 1}, Style{})
+
+	for _, x := range []int{2, 3} {
+		if actual := s.pixels[1][x].mainc; actual != '█' {
+			t.Errorf(`rune @ %d, 1 = %q, want '█'`, x, actual)
+		}
+	}
+	for _, p := range []image.Point{{0, 0}, {1, 1}, {3, 2}} {
+		if actual := s.pixels[p.Y][p.X].mainc; actual != ' ' {
+			t.Errorf(`rune @ %d, %d = %q, want ' '`, p.X, p.Y, actual)
+		}
+	}
+}
+
+// TestWriteANSI checks that images would be written with escape codes for
+// the colors of the palette, only when the colors change.
+func TestWriteANSI(t *testing.T) {
+	m := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	m.Set(0, 0, color.NRGBA{0xFF, 0, 0, 0xFF})
+	m.Set(1, 0, color.NRGBA{0xFF, 0, 0, 0xFF})
+	m.Set(2, 0, color.NRGBA{0, 0, 0xFF, 0xFF})
+	rgbRunes := conversion.RGBRunesFromImage(m)
+
+	for _, tt := range []struct {
+		style Style
+		want  string
+	}{
+		{Style{}, "\x1b[0m\x1b[38;2;255;0;0m██\x1b[0m\x1b[38;2;0;0;255m█\x1b[0m\n"},
+		{Style{Palette: Palette16}, "\x1b[0m\x1b[91m██\x1b[0m\x1b[94m█\x1b[0m\n"},
+		{Style{Palette: NoColor, Background: tcell.ColorBlack}, "\x1b[0m\x1b[40m███\x1b[0m\n"},
+	} {
+		var out bytes.Buffer
+		if err := WriteANSI(&out, rgbRunes, tt.style); err != nil {
+			t.Fatalf(`err = %v, want nil`, err)
+		}
+		if actual := out.String(); actual != tt.want {
+			t.Errorf(`%v: output = %q, want %q`, tt.style.Palette, actual, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package draw
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/spenserblack/termage/internal/conversion"
+)
+
+// Palette limits the colors that images are drawn with.
+type Palette int
+
+const (
+	// TrueColor draws images with 24-bit colors.
+	TrueColor Palette = iota
+	// Palette256 draws images with the 256 standard terminal colors.
+	Palette256
+	// Palette16 draws images with the 16 basic terminal colors.
+	Palette16
+	// NoColor draws images with the terminal's foreground color.
+	NoColor
+)
+
+// Names of palettes, as used in options.
+var paletteNames = map[Palette]string{
+	TrueColor:  "truecolor",
+	Palette256: "256",
+	Palette16:  "16",
+	NoColor:    "none",
+}
+
+// ParsePalette parses the name of a palette.
+func ParsePalette(name string) (Palette, error) {
+	for palette, paletteName := range paletteNames {
+		if name == paletteName {
+			return palette, nil
+		}
+	}
+	return TrueColor, fmt.Errorf("Unknown colors %q, want truecolor, 256, 16 or none", name)
+}
+
+// String gets the name of a palette.
+func (palette Palette) String() string {
+	return paletteNames[palette]
+}
+
+// Colors gets the colors in a palette, or nil if colors aren't limited to a
+// palette.
+func (palette Palette) colors() []tcell.Color {
+	var size int
+	switch palette {
+	case Palette256:
+		size = 256
+	case Palette16:
+		size = 16
+	default:
+		return nil
+	}
+	colors := make([]tcell.Color, size)
+	for i := range colors {
+		colors[i] = tcell.PaletteColor(i)
+	}
+	return colors
+}
+
+// Style configures how images are drawn.
+type Style struct {
+	Palette Palette
+	// Background is the background color of the image. The zero value is the
+	// terminal's background color.
+	Background tcell.Color
+}
+
+// PaletteCache caches the closest palette color of each color, as finding it
+// is expensive.
+var paletteCache = struct {
+	sync.Mutex
+	colors map[Palette]map[tcell.Color]tcell.Color
+}{colors: make(map[Palette]map[tcell.Color]tcell.Color)}
+
+// CellStyle gets the style of a single cell of an image.
+func (style Style) cellStyle(rgbRune conversion.RGBRune) tcell.Style {
+	cellStyle := tcell.StyleDefault.Background(style.Background)
+	if style.Palette == NoColor {
+		return cellStyle
+	}
+	return cellStyle.Foreground(style.Palette.find(tcell.FromImageColor(rgbRune)))
+}
+
+// Find finds the closest color in the palette.
+func (palette Palette) find(c tcell.Color) tcell.Color {
+	if palette == TrueColor || palette == NoColor {
+		return c
+	}
+	paletteCache.Lock()
+	defer paletteCache.Unlock()
+	cache, ok := paletteCache.colors[palette]
+	if !ok {
+		cache = make(map[tcell.Color]tcell.Color)
+		paletteCache.colors[palette] = cache
+	}
+	found, ok := cache[c]
+	if !ok {
+		found = tcell.FindColor(c, palette.colors())
+		cache[c] = found
+	}
+	return found
+}
@@ -0,0 +1,418 @@
+// Package exif reads EXIF metadata from JPEG, TIFF and WebP files.
+//
+// Only the tags that the viewer uses are read: the camera, the time the photo
+// was taken, its GPS position and its orientation.
+package exif
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNoEXIF signifies that a file doesn't contain EXIF metadata.
+var ErrNoEXIF = errors.New("No EXIF metadata")
+
+// TimeLayout is the layout of timestamps in EXIF metadata.
+const timeLayout = "2006:01:02 15:04:05"
+
+// Tags that are read.
+const (
+	tagMake             = 0x010F
+	tagModel            = 0x0110
+	tagOrientation      = 0x0112
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+	tagGPSLatitudeRef   = 0x0001
+	tagGPSLatitude      = 0x0002
+	tagGPSLongitudeRef  = 0x0003
+	tagGPSLongitude     = 0x0004
+	tagGPSAltitudeRef   = 0x0005
+	tagGPSAltitude      = 0x0006
+)
+
+// Types of tag values, and their sizes in bytes.
+const (
+	typeByte      = 1
+	typeASCII     = 2
+	typeShort     = 3
+	typeLong      = 4
+	typeRational  = 5
+	typeUndefined = 7
+	typeSLong     = 9
+	typeSRational = 10
+)
+
+var typeSizes = map[uint16]uint32{
+	typeByte:      1,
+	typeASCII:     1,
+	typeShort:     2,
+	typeLong:      4,
+	typeRational:  8,
+	typeUndefined: 1,
+	typeSLong:     4,
+	typeSRational: 8,
+}
+
+// EXIF is the metadata of a photo.
+type EXIF struct {
+	// Make is the manufacturer of the camera.
+	Make string
+	// Model is the model of the camera.
+	Model string
+	// Taken is when the photo was taken, or the zero time if it is unknown.
+	// EXIF timestamps don't have a time zone, so they are in UTC.
+	Taken time.Time
+	// Orientation is how the image must be transformed to be displayed
+	// upright.
+	Orientation Orientation
+	// GPS is where the photo was taken, or nil if it is unknown.
+	GPS *GPS
+}
+
+// GPS is a position on Earth.
+type GPS struct {
+	// Latitude is in degrees, with south being negative.
+	Latitude float64 `json:"latitude"`
+	// Longitude is in degrees, with west being negative.
+	Longitude float64 `json:"longitude"`
+	// Altitude is in meters above sea level.
+	Altitude float64 `json:"altitude"`
+}
+
+// String formats a position like "52.520000, 13.405000".
+func (gps GPS) String() string {
+	return fmt.Sprintf("%f, %f", gps.Latitude, gps.Longitude)
+}
+
+// Camera gets the make and model of the camera, without repeating the make
+// if the model already contains it.
+func (x *EXIF) Camera() string {
+	cameraMake, model := strings.TrimSpace(x.Make), strings.TrimSpace(x.Model)
+	if cameraMake == "" || strings.HasPrefix(strings.ToLower(model), strings.ToLower(cameraMake)) {
+		return model
+	}
+	if model == "" {
+		return cameraMake
+	}
+	return cameraMake + " " + model
+}
+
+// Field is a named, formatted piece of metadata.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Fields gets the metadata that is known, formatted for people to read.
+func (x *EXIF) Fields() []Field {
+	var fields []Field
+	if camera := x.Camera(); camera != "" {
+		fields = append(fields, Field{"Camera", camera})
+	}
+	if !x.Taken.IsZero() {
+		fields = append(fields, Field{"Taken", x.Taken.Format("2006-01-02 15:04:05")})
+	}
+	if x.Orientation != 0 {
+		fields = append(fields, Field{"Orientation", x.Orientation.String()})
+	}
+	if x.GPS != nil {
+		fields = append(fields, Field{"GPS", x.GPS.String()})
+		fields = append(fields, Field{"Altitude", fmt.Sprintf("%.1f m", x.GPS.Altitude)})
+	}
+	return fields
+}
+
+// Decode reads the EXIF metadata of a JPEG, TIFF or WebP file.
+func Decode(r io.Reader) (*EXIF, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, ErrNoEXIF
+	}
+	var data []byte
+	switch {
+	case magic[0] == 0xFF && magic[1] == 0xD8:
+		data, err = jpegTIFF(br)
+	case string(magic) == "RIFF":
+		data, err = webpTIFF(br)
+	case string(magic) == "II*\x00" || string(magic) == "MM\x00*":
+		data, err = io.ReadAll(br)
+	default:
+		return nil, ErrNoEXIF
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeTIFF(data)
+}
+
+// ExifHeader is the header that EXIF data starts with in JPEG and some WebP
+// files.
+const exifHeader = "Exif\x00\x00"
+
+// JPEGTIFF finds the TIFF structure in the APP1 segment of a JPEG.
+func jpegTIFF(r *bufio.Reader) ([]byte, error) {
+	if _, err := r.Discard(2); err != nil {
+		return nil, ErrNoEXIF
+	}
+	for {
+		marker, err := r.ReadByte()
+		if err != nil {
+			return nil, ErrNoEXIF
+		}
+		if marker != 0xFF {
+			return nil, fmt.Errorf("Invalid JPEG marker %#x", marker)
+		}
+		kind, err := r.ReadByte()
+		for err == nil && kind == 0xFF {
+			// NOTE Markers may be preceded by any number of fill bytes
+			kind, err = r.ReadByte()
+		}
+		if err != nil {
+			return nil, ErrNoEXIF
+		}
+		switch {
+		case kind == 0xD9 || kind == 0xDA:
+			// NOTE The image data starts, so there is no more metadata
+			return nil, ErrNoEXIF
+		case kind == 0x01 || (kind >= 0xD0 && kind <= 0xD7):
+			continue
+		}
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil || length < 2 {
+			return nil, ErrNoEXIF
+		}
+		segment := make([]byte, length-2)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return nil, ErrNoEXIF
+		}
+		if kind == 0xE1 && bytes.HasPrefix(segment, []byte(exifHeader)) {
+			return segment[len(exifHeader):], nil
+		}
+	}
+}
+
+// MaxWebPChunk is the largest EXIF chunk of a WebP that is read. It is the
+// size limit of EXIF metadata in a JPEG, so that a corrupt size can't make the
+// viewer allocate gigabytes.
+const maxWebPChunk = 64 << 10
+
+// WebPTIFF finds the TIFF structure in the EXIF chunk of a WebP.
+func webpTIFF(r *bufio.Reader) ([]byte, error) {
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil || string(header[8:]) != "WEBP" {
+		return nil, ErrNoEXIF
+	}
+	for {
+		chunkHeader := make([]byte, 8)
+		if _, err := io.ReadFull(r, chunkHeader); err != nil {
+			return nil, ErrNoEXIF
+		}
+		size := binary.LittleEndian.Uint32(chunkHeader[4:])
+		// NOTE Chunks are padded to an even size
+		padded := int64(size) + int64(size%2)
+		if string(chunkHeader[:4]) != "EXIF" {
+			if _, err := io.CopyN(io.Discard, r, padded); err != nil {
+				return nil, ErrNoEXIF
+			}
+			continue
+		}
+		if size > maxWebPChunk {
+			return nil, ErrNoEXIF
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, ErrNoEXIF
+		}
+		return bytes.TrimPrefix(chunk, []byte(exifHeader)), nil
+	}
+}
+
+// Tiff is a TIFF structure that IFDs are read from.
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// Entry is an entry of an IFD.
+type entry struct {
+	tag   uint16
+	kind  uint16
+	count uint32
+	value []byte
+}
+
+// DecodeTIFF reads EXIF metadata from a TIFF structure.
+func decodeTIFF(data []byte) (*EXIF, error) {
+	if len(data) < 8 {
+		return nil, ErrNoEXIF
+	}
+	t := tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("Invalid TIFF byte order %q", data[:2])
+	}
+	ifd0, err := t.ifd(t.order.Uint32(data[4:]))
+	if err != nil {
+		return nil, err
+	}
+
+	x := new(EXIF)
+	x.Make = t.ascii(ifd0[tagMake])
+	x.Model = t.ascii(ifd0[tagModel])
+	x.Orientation = Orientation(t.integer(ifd0[tagOrientation]))
+	if !x.Orientation.valid() {
+		x.Orientation = 0
+	}
+	x.Taken = parseTime(t.ascii(ifd0[tagDateTime]))
+
+	if e, ok := ifd0[tagExifIFD]; ok {
+		exifIFD, err := t.ifd(t.integer(e))
+		if err != nil {
+			return nil, err
+		}
+		if taken := parseTime(t.ascii(exifIFD[tagDateTimeOriginal])); !taken.IsZero() {
+			x.Taken = taken
+		}
+	}
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		gpsIFD, err := t.ifd(t.integer(e))
+		if err != nil {
+			return nil, err
+		}
+		x.GPS = t.gps(gpsIFD)
+	}
+	return x, nil
+}
+
+// IFD reads the entries of the IFD at an offset.
+func (t tiff) ifd(offset uint32) (map[uint16]entry, error) {
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return nil, fmt.Errorf("IFD offset %d is out of bounds", offset)
+	}
+	count := uint32(t.order.Uint16(t.data[offset:]))
+	start := offset + 2
+	if uint64(start)+uint64(count)*12 > uint64(len(t.data)) {
+		return nil, fmt.Errorf("IFD at %d is out of bounds", offset)
+	}
+	entries := make(map[uint16]entry, count)
+	for i := uint32(0); i < count; i++ {
+		raw := t.data[start+i*12 : start+(i+1)*12]
+		e := entry{
+			tag:   t.order.Uint16(raw),
+			kind:  t.order.Uint16(raw[2:]),
+			count: t.order.Uint32(raw[4:]),
+		}
+		typeSize, ok := typeSizes[e.kind]
+		if !ok {
+			continue
+		}
+		size := uint64(typeSize) * uint64(e.count)
+		if size <= 4 {
+			e.value = raw[8 : 8+size]
+		} else {
+			valueOffset := uint64(t.order.Uint32(raw[8:]))
+			if valueOffset+size > uint64(len(t.data)) {
+				continue
+			}
+			e.value = t.data[valueOffset : valueOffset+size]
+		}
+		entries[e.tag] = e
+	}
+	return entries, nil
+}
+
+// ASCII gets the value of an ASCII entry, or an empty string if it isn't
+// one.
+func (t tiff) ascii(e entry) string {
+	if e.kind != typeASCII {
+		return ""
+	}
+	return strings.TrimRight(string(e.value), "\x00")
+}
+
+// Integer gets the first value of an integer entry, or 0 if it isn't one.
+func (t tiff) integer(e entry) uint32 {
+	switch {
+	case e.count == 0:
+		return 0
+	case e.kind == typeByte:
+		return uint32(e.value[0])
+	case e.kind == typeShort:
+		return uint32(t.order.Uint16(e.value))
+	case e.kind == typeLong:
+		return t.order.Uint32(e.value)
+	}
+	return 0
+}
+
+// Rationals gets the values of a rational entry.
+func (t tiff) rationals(e entry) []float64 {
+	if e.kind != typeRational {
+		return nil
+	}
+	values := make([]float64, e.count)
+	for i := range values {
+		numerator := t.order.Uint32(e.value[i*8:])
+		denominator := t.order.Uint32(e.value[i*8+4:])
+		if denominator != 0 {
+			values[i] = float64(numerator) / float64(denominator)
+		}
+	}
+	return values
+}
+
+// GPS reads the position from a GPS IFD, or nil if the latitude or longitude
+// is missing.
+func (t tiff) gps(ifd map[uint16]entry) *GPS {
+	degrees := func(tag, refTag uint16, negative string) (float64, bool) {
+		parts := t.rationals(ifd[tag])
+		if len(parts) != 3 {
+			return 0, false
+		}
+		value := parts[0] + parts[1]/60 + parts[2]/3600
+		if t.ascii(ifd[refTag]) == negative {
+			value = -value
+		}
+		return value, true
+	}
+	latitude, ok := degrees(tagGPSLatitude, tagGPSLatitudeRef, "S")
+	if !ok {
+		return nil
+	}
+	longitude, ok := degrees(tagGPSLongitude, tagGPSLongitudeRef, "W")
+	if !ok {
+		return nil
+	}
+	gps := &GPS{Latitude: latitude, Longitude: longitude}
+	if altitude := t.rationals(ifd[tagGPSAltitude]); len(altitude) == 1 {
+		gps.Altitude = altitude[0]
+		// NOTE A reference of 1 means below sea level
+		if t.integer(ifd[tagGPSAltitudeRef]) == 1 {
+			gps.Altitude = -gps.Altitude
+		}
+	}
+	return gps
+}
+
+// ParseTime parses an EXIF timestamp, returning the zero time if it is
+// invalid.
+func parseTime(s string) time.Time {
+	taken, err := time.Parse(timeLayout, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}
+	}
+	return taken
+}
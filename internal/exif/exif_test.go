@@ -0,0 +1,272 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+// TestEntry is an IFD entry to write in a test TIFF structure.
+type testEntry struct {
+	tag   uint16
+	kind  uint16
+	count uint32
+	value []byte
+}
+
+// ByteOrder is a byte order that can both put and append integers.
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// TIFFBuilder writes TIFF structures for tests.
+type tiffBuilder struct {
+	order byteOrder
+	data  []byte
+}
+
+func newTIFFBuilder(order byteOrder) *tiffBuilder {
+	b := &tiffBuilder{order: order}
+	if order == binary.LittleEndian {
+		b.data = []byte("II*\x00\x00\x00\x00\x00")
+	} else {
+		b.data = []byte("MM\x00*\x00\x00\x00\x00")
+	}
+	return b
+}
+
+// IFD appends an IFD and the values that don't fit in its entries, and
+// returns its offset.
+func (b *tiffBuilder) ifd(entries ...testEntry) uint32 {
+	offset := uint32(len(b.data))
+	valuesOffset := offset + 2 + uint32(len(entries))*12 + 4
+	var values []byte
+	b.data = b.order.AppendUint16(b.data, uint16(len(entries)))
+	for _, e := range entries {
+		b.data = b.order.AppendUint16(b.data, e.tag)
+		b.data = b.order.AppendUint16(b.data, e.kind)
+		b.data = b.order.AppendUint32(b.data, e.count)
+		if len(e.value) <= 4 {
+			b.data = append(b.data, e.value...)
+			b.data = append(b.data, make([]byte, 4-len(e.value))...)
+			continue
+		}
+		b.data = b.order.AppendUint32(b.data, valuesOffset+uint32(len(values)))
+		values = append(values, e.value...)
+	}
+	b.data = b.order.AppendUint32(b.data, 0)
+	b.data = append(b.data, values...)
+	return offset
+}
+
+// Finish sets the offset of IFD0 and returns the TIFF structure.
+func (b *tiffBuilder) finish(ifd0 uint32) []byte {
+	b.order.PutUint32(b.data[4:], ifd0)
+	return b.data
+}
+
+func (b *tiffBuilder) ascii(tag uint16, s string) testEntry {
+	return testEntry{tag, typeASCII, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func (b *tiffBuilder) short(tag uint16, v uint16) testEntry {
+	return testEntry{tag, typeShort, 1, b.order.AppendUint16(nil, v)}
+}
+
+func (b *tiffBuilder) long(tag uint16, v uint32) testEntry {
+	return testEntry{tag, typeLong, 1, b.order.AppendUint32(nil, v)}
+}
+
+func (b *tiffBuilder) rationals(tag uint16, values ...[2]uint32) testEntry {
+	var value []byte
+	for _, v := range values {
+		value = b.order.AppendUint32(value, v[0])
+		value = b.order.AppendUint32(value, v[1])
+	}
+	return testEntry{tag, typeRational, uint32(len(values)), value}
+}
+
+// PhotoTIFF builds the TIFF structure of a photo taken with a phone.
+func photoTIFF(order byteOrder) []byte {
+	b := newTIFFBuilder(order)
+	exifIFD := b.ifd(b.ascii(tagDateTimeOriginal, "2021:06:01 12:30:00"))
+	gpsIFD := b.ifd(
+		b.ascii(tagGPSLatitudeRef, "N"),
+		b.rationals(tagGPSLatitude, [2]uint32{52, 1}, [2]uint32{30, 1}, [2]uint32{0, 1}),
+		b.ascii(tagGPSLongitudeRef, "W"),
+		b.rationals(tagGPSLongitude, [2]uint32{13, 1}, [2]uint32{15, 1}, [2]uint32{36, 1}),
+		testEntry{tagGPSAltitudeRef, typeByte, 1, []byte{0}},
+		b.rationals(tagGPSAltitude, [2]uint32{345, 10}),
+	)
+	ifd0 := b.ifd(
+		b.ascii(tagMake, "Apple"),
+		b.ascii(tagModel, "iPhone 12"),
+		b.short(tagOrientation, uint16(Rotate90)),
+		b.ascii(tagDateTime, "2022:01:01 00:00:00"),
+		b.long(tagExifIFD, exifIFD),
+		b.long(tagGPSIFD, gpsIFD),
+	)
+	return b.finish(ifd0)
+}
+
+// JPEG wraps a TIFF structure in the APP1 segment of a JPEG.
+func jpeg(tiff []byte) []byte {
+	segment := append([]byte(exifHeader), tiff...)
+	data := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x04, 'J', 'F'}
+	data = append(data, 0xFF, 0xE1)
+	data = binary.BigEndian.AppendUint16(data, uint16(len(segment)+2))
+	data = append(data, segment...)
+	return append(data, 0xFF, 0xDA, 0x00, 0x02)
+}
+
+// WebP wraps a TIFF structure in the EXIF chunk of a WebP.
+func webp(tiff []byte) []byte {
+	var chunks []byte
+	chunks = append(chunks, "VP8X"...)
+	chunks = binary.LittleEndian.AppendUint32(chunks, 3)
+	chunks = append(chunks, 0, 0, 0, 0)
+	chunks = append(chunks, "EXIF"...)
+	chunks = binary.LittleEndian.AppendUint32(chunks, uint32(len(tiff)))
+	chunks = append(chunks, tiff...)
+	data := []byte("RIFF")
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(chunks)+4))
+	data = append(data, "WEBP"...)
+	return append(data, chunks...)
+}
+
+// CheckPhoto checks that the metadata of photoTIFF was decoded.
+func checkPhoto(t *testing.T, x *EXIF, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if actual, want := x.Camera(), "Apple iPhone 12"; actual != want {
+		t.Errorf(`Camera() = %q, want %q`, actual, want)
+	}
+	if want := time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC); !x.Taken.Equal(want) {
+		t.Errorf(`Taken = %v, want %v`, x.Taken, want)
+	}
+	if x.Orientation != Rotate90 {
+		t.Errorf(`Orientation = %v, want %v`, x.Orientation, Rotate90)
+	}
+	if x.GPS == nil {
+		t.Fatalf(`GPS = nil`)
+	}
+	if want := (GPS{52.5, -13.26, 34.5}); *x.GPS != want {
+		t.Errorf(`GPS = %+v, want %+v`, *x.GPS, want)
+	}
+}
+
+// TestDecodeJPEG checks that metadata would be read from a JPEG.
+func TestDecodeJPEG(t *testing.T) {
+	x, err := Decode(bytes.NewReader(jpeg(photoTIFF(binary.BigEndian))))
+	checkPhoto(t, x, err)
+}
+
+// TestDecodeTIFF checks that metadata would be read from a little-endian
+// TIFF.
+func TestDecodeTIFF(t *testing.T) {
+	x, err := Decode(bytes.NewReader(photoTIFF(binary.LittleEndian)))
+	checkPhoto(t, x, err)
+}
+
+// TestDecodeWebP checks that metadata would be read from a WebP, skipping
+// other chunks.
+func TestDecodeWebP(t *testing.T) {
+	x, err := Decode(bytes.NewReader(webp(photoTIFF(binary.LittleEndian))))
+	checkPhoto(t, x, err)
+}
+
+// TestDecodeNoEXIF checks that files without metadata would return
+// ErrNoEXIF.
+func TestDecodeNoEXIF(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"JPEG":    {0xFF, 0xD8, 0xFF, 0xDA, 0x00, 0x02},
+		"PNG":     []byte("\x89PNG\r\n\x1a\n"),
+		"empty":   nil,
+		"WebP":    []byte("RIFF\x04\x00\x00\x00WEBP"),
+		"garbage": []byte("hello, world"),
+	} {
+		if _, err := Decode(bytes.NewReader(data)); !errors.Is(err, ErrNoEXIF) {
+			t.Errorf(`%s: err = %v, want %v`, name, err, ErrNoEXIF)
+		}
+	}
+}
+
+// TestDecodeWebPTooLarge checks that a WebP with an EXIF chunk that claims
+// to be larger than the limit would return ErrNoEXIF.
+func TestDecodeWebPTooLarge(t *testing.T) {
+	data := webp(photoTIFF(binary.LittleEndian))
+	// NOTE The size of the EXIF chunk follows the 12 byte header and the
+	//      12 byte VP8X chunk
+	binary.LittleEndian.PutUint32(data[28:], 0xFFFFFFFF)
+
+	if _, err := Decode(bytes.NewReader(data)); !errors.Is(err, ErrNoEXIF) {
+		t.Errorf(`err = %v, want %v`, err, ErrNoEXIF)
+	}
+}
+
+// TestDecodeTruncated checks that a TIFF structure that points outside of
+// itself would be an error rather than a panic.
+func TestDecodeTruncated(t *testing.T) {
+	data := photoTIFF(binary.LittleEndian)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)))
+
+	if _, err := Decode(bytes.NewReader(data)); err == nil {
+		t.Errorf(`err = nil`)
+	}
+}
+
+// TestFields checks that only known metadata would be listed.
+func TestFields(t *testing.T) {
+	x := &EXIF{Model: "NIKON D750", Make: "NIKON", Orientation: Normal}
+	fields := x.Fields()
+
+	want := []Field{{"Camera", "NIKON D750"}, {"Orientation", "Normal"}}
+	if len(fields) != len(want) {
+		t.Fatalf(`fields = %v, want %v`, fields, want)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf(`fields[%d] = %v, want %v`, i, fields[i], want[i])
+		}
+	}
+}
+
+// TestApply checks that images would be rotated and mirrored to be upright.
+func TestApply(t *testing.T) {
+	// NOTE A 2x1 image with a white pixel on the left
+	m := image.NewGray(image.Rect(0, 0, 2, 1))
+	m.SetGray(0, 0, color.Gray{0xFF})
+
+	for _, tt := range []struct {
+		orientation Orientation
+		size        image.Point
+		white       image.Point
+	}{
+		{Normal, image.Point{2, 1}, image.Point{0, 0}},
+		{MirrorHorizontal, image.Point{2, 1}, image.Point{1, 0}},
+		{Rotate180, image.Point{2, 1}, image.Point{1, 0}},
+		{Rotate90, image.Point{1, 2}, image.Point{0, 0}},
+		{Rotate270, image.Point{1, 2}, image.Point{0, 1}},
+		{MirrorHorizontalRotate270, image.Point{1, 2}, image.Point{0, 0}},
+		{MirrorHorizontalRotate90, image.Point{1, 2}, image.Point{0, 1}},
+	} {
+		if swaps := tt.size.X == 1; tt.orientation.Swaps() != swaps {
+			t.Errorf(`%v: Swaps() = %v, want %v`, tt.orientation, !swaps, swaps)
+		}
+		upright := tt.orientation.Apply(m)
+		if size := upright.Bounds().Size(); size != tt.size {
+			t.Errorf(`%v: size = %v, want %v`, tt.orientation, size, tt.size)
+			continue
+		}
+		if r, _, _, _ := upright.At(tt.white.X, tt.white.Y).RGBA(); r != 0xFFFF {
+			t.Errorf(`%v: pixel %v is not white`, tt.orientation, tt.white)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package exif
+
+import (
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+// Orientation is the EXIF orientation tag, which tells how an image must be
+// transformed to be displayed upright. 0 is an unknown orientation.
+type Orientation int
+
+// Orientations defined by EXIF.
+const (
+	Normal Orientation = 1 + iota
+	MirrorHorizontal
+	Rotate180
+	MirrorVertical
+	MirrorHorizontalRotate270
+	Rotate90
+	MirrorHorizontalRotate90
+	Rotate270
+)
+
+// Descriptions of orientations.
+var orientationNames = map[Orientation]string{
+	Normal:                    "Normal",
+	MirrorHorizontal:          "Mirror horizontal",
+	Rotate180:                 "Rotate 180",
+	MirrorVertical:            "Mirror vertical",
+	MirrorHorizontalRotate270: "Mirror horizontal and rotate 270 CW",
+	Rotate90:                  "Rotate 90 CW",
+	MirrorHorizontalRotate90:  "Mirror horizontal and rotate 90 CW",
+	Rotate270:                 "Rotate 270 CW",
+}
+
+// String describes the orientation, like "Rotate 90 CW".
+func (o Orientation) String() string {
+	if name, ok := orientationNames[o]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// Valid checks if the orientation is defined by EXIF.
+func (o Orientation) valid() bool {
+	_, ok := orientationNames[o]
+	return ok
+}
+
+// Swaps checks if the orientation swaps the width and the height of images.
+func (o Orientation) Swaps() bool {
+	switch o {
+	case MirrorHorizontalRotate270, Rotate90, MirrorHorizontalRotate90, Rotate270:
+		return true
+	}
+	return false
+}
+
+// Apply transforms an image so that it is upright. Images that are already
+// upright are returned unchanged.
+func (o Orientation) Apply(m image.Image) image.Image {
+	// NOTE imaging rotates counter-clockwise, and EXIF clockwise
+	switch o {
+	case MirrorHorizontal:
+		return imaging.FlipH(m)
+	case Rotate180:
+		return imaging.Rotate180(m)
+	case MirrorVertical:
+		return imaging.FlipV(m)
+	case MirrorHorizontalRotate270:
+		return imaging.Transpose(m)
+	case Rotate90:
+		return imaging.Rotate270(m)
+	case MirrorHorizontalRotate90:
+		return imaging.Transverse(m)
+	case Rotate270:
+		return imaging.Rotate90(m)
+	}
+	return m
+}
@@ -193,6 +193,52 @@ func TestBrowserBack(t *testing.T) {
 	}
 }
 
+// TestBrowserFirstLast ensures that the browser moves to the first and last
+// files.
+func TestBrowserFirstLast(t *testing.T) {
+	fb := FileBrowser{1, []string{"1", "2", "3"}}
+
+	fb.Last()
+	if actual := fb.Index(); actual != 2 {
+		t.Errorf(`Last moved to index %d, want 2`, actual)
+	}
+	fb.First()
+	if actual := fb.Index(); actual != 0 {
+		t.Errorf(`First moved to index %d, want 0`, actual)
+	}
+	if actual := fb.Len(); actual != 3 {
+		t.Errorf(`Len() = %d, want 3`, actual)
+	}
+}
+
+// TestBrowserSeek ensures that the browser moves to an index, wrapping
+// around both ends.
+func TestBrowserSeek(t *testing.T) {
+	fb := FileBrowser{0, []string{"1", "2", "3"}}
+
+	for index, want := range map[int]string{2: "3", 3: "1", -1: "3"} {
+		fb.Seek(index)
+		if actual := fb.Current(); actual != want {
+			t.Errorf(`Seek(%d) moved to %q, want %q`, index, actual, want)
+		}
+	}
+}
+
+// TestBrowserPeek ensures that the browser gets files around the current
+// file, wrapping around both ends, without moving.
+func TestBrowserPeek(t *testing.T) {
+	fb := FileBrowser{1, []string{"1", "2", "3"}}
+
+	for offset, want := range map[int]string{-4: "1", -1: "1", 0: "2", 1: "3", 2: "1"} {
+		if actual := fb.Peek(offset); actual != want {
+			t.Errorf(`Peek(%d) = %q, want %q`, offset, actual, want)
+		}
+	}
+	if actual := fb.Current(); actual != "2" {
+		t.Errorf(`Peek moved the browser to %q`, actual)
+	}
+}
+
 // TestNonexistent checks that an error is returned when a non-existent
 // directory/file is attempted to be opened.
 func TestNonexistent(t *testing.T) {
@@ -244,3 +290,37 @@ func TestFilePathAbsError(t *testing.T) {
 		t.Errorf(`err = %v, want %v`, err, want)
 	}
 }
+
+// TestRemove checks that removing a file would keep the browser on the same
+// file, or on the next file if the current file was removed.
+func TestRemove(t *testing.T) {
+	browser := FileBrowser{2, []string{"a", "b", "c", "d"}}
+
+	browser.Remove("a")
+	if actual := browser.Current(); actual != "c" {
+		t.Errorf(`current file after removing an earlier file = %q, want "c"`, actual)
+	}
+	browser.Remove("c")
+	if actual := browser.Current(); actual != "d" {
+		t.Errorf(`current file after removing it = %q, want "d"`, actual)
+	}
+	browser.Remove("d")
+	if actual := browser.Current(); actual != "b" {
+		t.Errorf(`current file after removing the last file = %q, want "b"`, actual)
+	}
+	if browser.Remove("missing") {
+		t.Errorf(`removed a file that isn't browsed`)
+	}
+}
+
+// TestReplace checks that a replaced file would stay in its place.
+func TestReplace(t *testing.T) {
+	browser := FileBrowser{1, []string{"a", "b", "c"}}
+
+	if !browser.Replace("b", "renamed") {
+		t.Fatalf(`file wasn't replaced`)
+	}
+	if actual := browser.Current(); actual != "renamed" {
+		t.Errorf(`current file = %q, want "renamed"`, actual)
+	}
+}
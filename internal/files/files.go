@@ -25,6 +25,13 @@ var (
 // or directory. If it is a file, then that is the initial file selected by the
 // returned FileBrowser. If it is a directory, then the index will start at 0.
 func NewFileBrowser(filename string, extensions map[string]struct{}) (browser FileBrowser, err error) {
+	return NewFilteredFileBrowser(filename, extensions, Filter{})
+}
+
+// NewFilteredFileBrowser is like NewFileBrowser, but only browses files that
+// pass a filter. If the file that is pointed to does not pass the filter, the
+// index will start at 0.
+func NewFilteredFileBrowser(filename string, extensions map[string]struct{}, filter Filter) (browser FileBrowser, err error) {
 	var currentDir string
 	absoluteFilename, err := absPath(filename)
 	if err != nil {
@@ -58,6 +65,11 @@ func NewFileBrowser(filename string, extensions map[string]struct{}) (browser Fi
 		if fileStats.IsDir() {
 			continue
 		}
+		if ok, err := filter.Match(absFpath); err != nil {
+			return browser, err
+		} else if !ok {
+			continue
+		}
 		if os.SameFile(currentFileStats, fileStats) {
 			browser.index = len(browser.Filenames)
 		}
@@ -80,11 +92,75 @@ func (browser *FileBrowser) Back() {
 	}
 }
 
+// First moves to the first file.
+func (browser *FileBrowser) First() {
+	browser.index = 0
+}
+
+// Last moves to the last file.
+func (browser *FileBrowser) Last() {
+	browser.index = len(browser.Filenames) - 1
+}
+
+// Len gets the number of files that can be browsed.
+func (browser *FileBrowser) Len() int {
+	return len(browser.Filenames)
+}
+
+// Index gets the index of the current file.
+func (browser *FileBrowser) Index() int {
+	return browser.index
+}
+
+// Seek moves to the file at an index. Indices out of range wrap around.
+func (browser *FileBrowser) Seek(index int) {
+	length := len(browser.Filenames)
+	browser.index = (index%length + length) % length
+}
+
+// Peek gets the file that is offset files away from the current file, without
+// moving to it.
+func (browser *FileBrowser) Peek(offset int) string {
+	length := len(browser.Filenames)
+	index := ((browser.index+offset)%length + length) % length
+	return browser.Filenames[index]
+}
+
 // Current gets the current file.
 func (browser *FileBrowser) Current() string {
 	return browser.Filenames[browser.index]
 }
 
+// Remove stops browsing a file. If it is the current file, the file after it
+// becomes the current file, or the file before it if it was the last file.
+// It returns false if the file isn't browsed.
+func (browser *FileBrowser) Remove(filename string) bool {
+	for i, f := range browser.Filenames {
+		if f != filename {
+			continue
+		}
+		browser.Filenames = append(browser.Filenames[:i], browser.Filenames[i+1:]...)
+		if i < browser.index || browser.index >= len(browser.Filenames) && browser.index > 0 {
+			browser.index--
+		}
+		return true
+	}
+	return false
+}
+
+// Replace replaces a browsed file with another file, like the new path of a
+// renamed file, in the same place. It returns false if the file isn't
+// browsed.
+func (browser *FileBrowser) Replace(filename, replacement string) bool {
+	for i, f := range browser.Filenames {
+		if f == filename {
+			browser.Filenames[i] = replacement
+			return true
+		}
+	}
+	return false
+}
+
 func newFileBrowserError(filename string, err error) error {
 	return fmt.Errorf("Couldn't initialize file browser for %q: %w", filename, err)
 }
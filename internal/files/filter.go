@@ -0,0 +1,151 @@
+package files
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Filter decides which files should be browsed. The zero value allows all
+// files.
+type Filter struct {
+	// Include contains glob patterns. If any include patterns or regular
+	// expressions are set, files must match at least one of them.
+	Include []string
+	// Exclude contains glob patterns. Files matching any of them are skipped.
+	Exclude []string
+	// IncludeRegexp is like Include, but for regular expressions.
+	IncludeRegexp []*regexp.Regexp
+	// ExcludeRegexp is like Exclude, but for regular expressions.
+	ExcludeRegexp []*regexp.Regexp
+	// MinSize is the minimum width and height of images. A dimension of 0 is
+	// not limited.
+	MinSize image.Point
+	// MaxSize is the maximum width and height of images. A dimension of 0 is
+	// not limited.
+	MaxSize image.Point
+}
+
+// For mocking functions.
+var decodeConfig = defaultDecodeConfig
+
+// DefaultDecodeConfig decodes the dimensions of an image file.
+func defaultDecodeConfig(filename string) (image.Config, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return image.Config{}, err
+	}
+	defer f.Close()
+	config, _, err := image.DecodeConfig(f)
+	return config, err
+}
+
+// Match checks if a file passes the filter.
+//
+// Glob patterns containing a path separator are matched against the whole
+// filename, other patterns are matched against the file's base name. Regular
+// expressions are matched against the whole filename.
+func (filter Filter) Match(filename string) (bool, error) {
+	hasIncludes := len(filter.Include) > 0 || len(filter.IncludeRegexp) > 0
+	if hasIncludes {
+		included, err := matchAny(filename, filter.Include, filter.IncludeRegexp)
+		if err != nil || !included {
+			return false, err
+		}
+	}
+	excluded, err := matchAny(filename, filter.Exclude, filter.ExcludeRegexp)
+	if err != nil || excluded {
+		return false, err
+	}
+	if filter.MinSize == (image.Point{}) && filter.MaxSize == (image.Point{}) {
+		return true, nil
+	}
+	config, err := decodeConfig(filename)
+	if err != nil {
+		// NOTE Files that can't be measured can't satisfy size limits
+		return false, nil
+	}
+	return inSizeRange(config.Width, filter.MinSize.X, filter.MaxSize.X) &&
+		inSizeRange(config.Height, filter.MinSize.Y, filter.MaxSize.Y), nil
+}
+
+// Apply returns only the files that pass the filter.
+func (filter Filter) Apply(filenames []string) ([]string, error) {
+	filtered := make([]string, 0, len(filenames))
+	for _, filename := range filenames {
+		ok, err := filter.Match(filename)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			filtered = append(filtered, filename)
+		}
+	}
+	return filtered, nil
+}
+
+// ParseSize parses a size formatted as WIDTHxHEIGHT. Either dimension can be
+// left empty to leave it unlimited, like "640x" or "x480".
+func ParseSize(s string) (size image.Point, err error) {
+	sep := strings.IndexAny(s, "xX")
+	if sep < 0 || s == "x" || s == "X" {
+		return size, fmt.Errorf("Invalid size %q, want WIDTHxHEIGHT", s)
+	}
+	width, height := s[:sep], s[sep+1:]
+	if width != "" {
+		if size.X, err = strconv.Atoi(width); err != nil || size.X < 0 {
+			return size, fmt.Errorf("Invalid width in size %q", s)
+		}
+	}
+	if height != "" {
+		if size.Y, err = strconv.Atoi(height); err != nil || size.Y < 0 {
+			return size, fmt.Errorf("Invalid height in size %q", s)
+		}
+	}
+	return size, nil
+}
+
+// MatchAny checks if a filename matches any glob pattern or regular expression.
+func matchAny(filename string, globs []string, regexps []*regexp.Regexp) (bool, error) {
+	base := filepath.Base(filename)
+	for _, pattern := range globs {
+		name := base
+		if containsSeparator(pattern) {
+			name = filename
+		}
+		matched, err := filepath.Match(pattern, name)
+		if err != nil {
+			return false, fmt.Errorf("Invalid glob pattern %q: %w", pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	for _, re := range regexps {
+		if re.MatchString(filename) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ContainsSeparator checks if a pattern contains a path separator.
+func containsSeparator(pattern string) bool {
+	return strings.ContainsRune(pattern, '/') || strings.ContainsRune(pattern, filepath.Separator)
+}
+
+// InSizeRange checks if a dimension is within limits, where a limit of 0 means
+// no limit.
+func inSizeRange(dimension, min, max int) bool {
+	if min > 0 && dimension < min {
+		return false
+	}
+	if max > 0 && dimension > max {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package files
+
+import (
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// TestFilterGlobs checks that files would be included and excluded by glob
+// patterns.
+func TestFilterGlobs(t *testing.T) {
+	filter := Filter{
+		Include: []string{"*_diff.png"},
+		Exclude: []string{"skip_*"},
+	}
+	filenameToWant := map[string]bool{
+		filepath.Join("out", "a_diff.png"):      true,
+		filepath.Join("out", "a_expected.png"):  false,
+		filepath.Join("out", "skip_a_diff.png"): false,
+	}
+	for filename, want := range filenameToWant {
+		actual, err := filter.Match(filename)
+		if err != nil {
+			t.Fatalf(`err = %v, want nil`, err)
+		}
+		if actual != want {
+			t.Errorf(`Match(%q) = %v, want %v`, filename, actual, want)
+		}
+	}
+}
+
+// TestFilterRegexps checks that files would be included and excluded by
+// regular expressions.
+func TestFilterRegexps(t *testing.T) {
+	filter := Filter{
+		IncludeRegexp: []*regexp.Regexp{regexp.MustCompile(`\d+\.png$`)},
+		ExcludeRegexp: []*regexp.Regexp{regexp.MustCompile(`^old`)},
+	}
+
+	filtered, err := filter.Apply([]string{"1.png", "a.png", "old2.png", "new3.png"})
+
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	want := []string{"1.png", "new3.png"}
+	if len(filtered) != len(want) {
+		t.Fatalf(`filtered = %v, want %v`, filtered, want)
+	}
+	for i, v := range want {
+		if filtered[i] != v {
+			t.Errorf(`filtered[%d] = %q, want %q`, i, filtered[i], v)
+		}
+	}
+}
+
+// TestFilterBadGlob checks that an invalid glob pattern returns an error.
+func TestFilterBadGlob(t *testing.T) {
+	filter := Filter{Include: []string{"["}}
+
+	if _, err := filter.Match("a.png"); err == nil {
+		t.Errorf(`err = nil`)
+	}
+}
+
+// TestFilterSize checks that files would be filtered by the dimensions of the
+// image.
+func TestFilterSize(t *testing.T) {
+	decodeConfig = func(filename string) (image.Config, error) {
+		switch filename {
+		case "small.png":
+			return image.Config{Width: 10, Height: 10}, nil
+		case "wide.png":
+			return image.Config{Width: 1000, Height: 10}, nil
+		case "big.png":
+			return image.Config{Width: 100, Height: 100}, nil
+		}
+		return image.Config{}, errors.New("mocked")
+	}
+	defer func() {
+		decodeConfig = defaultDecodeConfig
+	}()
+	filter := Filter{MinSize: image.Point{50, 0}, MaxSize: image.Point{500, 0}}
+
+	filtered, err := filter.Apply([]string{"small.png", "wide.png", "big.png", "broken.png"})
+
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if len(filtered) != 1 || filtered[0] != "big.png" {
+		t.Errorf(`filtered = %v, want [big.png]`, filtered)
+	}
+}
+
+// TestParseSize checks that sizes would be parsed with optional dimensions.
+func TestParseSize(t *testing.T) {
+	strToSize := map[string]image.Point{
+		"640x480": {640, 480},
+		"640x":    {640, 0},
+		"X480":    {0, 480},
+	}
+	for s, want := range strToSize {
+		actual, err := ParseSize(s)
+		if err != nil {
+			t.Fatalf(`err = %v, want nil`, err)
+		}
+		if actual != want {
+			t.Errorf(`ParseSize(%q) = %v, want %v`, s, actual, want)
+		}
+	}
+	for _, s := range []string{"", "640", "x", "ax480", "-1x2"} {
+		if _, err := ParseSize(s); err == nil {
+			t.Errorf(`ParseSize(%q) err = nil`, s)
+		}
+	}
+}
+
+// TestFilteredBrowser checks that a file browser would skip filtered files.
+func TestFilteredBrowser(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"a_diff.png", "a.png", "b_diff.jpg"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), nil, 0600); err != nil {
+			panic(err)
+		}
+	}
+
+	browser, err := NewFilteredFileBrowser(tempDir, imageExtensions, Filter{Include: []string{"*_diff.*"}})
+
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if actual, want := len(browser.Filenames), 2; actual != want {
+		t.Errorf(`got %d files, want %d`, actual, want)
+	}
+}
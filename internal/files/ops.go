@@ -0,0 +1,210 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// For mocking functions.
+var (
+	getenv      = os.Getenv
+	userHomeDir = os.UserHomeDir
+	now         = time.Now
+)
+
+// TrashDir gets the directory of the home trash, which is
+// $XDG_DATA_HOME/Trash.
+func TrashDir() (string, error) {
+	dataHome := getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := userHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("Couldn't find trash directory: %w", err)
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+// Trash moves a file to the home trash, as described by the freedesktop.org
+// trash specification, so that file managers can restore it.
+//
+// Files on other file systems than the trash are copied to the trash and then
+// removed, instead of being moved to a trash on their own file system.
+func Trash(filename string) error {
+	absolute, err := absPath(filename)
+	if err != nil {
+		return err
+	}
+	dir, err := TrashDir()
+	if err != nil {
+		return err
+	}
+	filesDir, infoDir := filepath.Join(dir, "files"), filepath.Join(dir, "info")
+	for _, d := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(d, 0o700); err != nil {
+			return fmt.Errorf("Couldn't create trash directory: %w", err)
+		}
+	}
+	info, name, err := createTrashInfo(infoDir, filepath.Base(absolute))
+	if err != nil {
+		return err
+	}
+	escaped := (&url.URL{Path: absolute}).EscapedPath()
+	_, err = fmt.Fprintf(info, "[Trash Info]\nPath=%s\nDeletionDate=%s\n", escaped, now().Format("2006-01-02T15:04:05"))
+	if closeErr := info.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = move(absolute, filepath.Join(filesDir, name))
+	}
+	if err != nil {
+		// NOTE The file wasn't trashed, so it shouldn't be listed as trashed
+		os.Remove(filepath.Join(infoDir, name+".trashinfo"))
+		return fmt.Errorf("Couldn't move %q to the trash: %w", filename, err)
+	}
+	return nil
+}
+
+// CreateTrashInfo creates the info file of a file in the trash, with a name
+// that no other trashed file has. The name is the base name, with a number
+// added if it is taken.
+func createTrashInfo(infoDir, base string) (*os.File, string, error) {
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name = stem + "." + strconv.Itoa(i) + ext
+		}
+		// NOTE Creating the info file reserves the name, as the specification
+		//      requires
+		f, err := os.OpenFile(filepath.Join(infoDir, name+".trashinfo"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("Couldn't create trash info: %w", err)
+		}
+		return f, name, nil
+	}
+}
+
+// MoveTo moves a file into a directory, and returns its new path. Files in
+// the directory are not replaced.
+func MoveTo(filename, dir string) (string, error) {
+	if err := checkDir(dir); err != nil {
+		return "", err
+	}
+	target := filepath.Join(dir, filepath.Base(filename))
+	if err := checkTarget(target); err != nil {
+		return "", err
+	}
+	if err := move(filename, target); err != nil {
+		return "", fmt.Errorf("Couldn't move %q to %q: %w", filename, dir, err)
+	}
+	return target, nil
+}
+
+// CopyTo copies a file into a directory, and returns the path of the copy.
+// Files in the directory are not replaced.
+func CopyTo(filename, dir string) (string, error) {
+	if err := checkDir(dir); err != nil {
+		return "", err
+	}
+	target := filepath.Join(dir, filepath.Base(filename))
+	if err := checkTarget(target); err != nil {
+		return "", err
+	}
+	if err := copyFile(filename, target); err != nil {
+		return "", fmt.Errorf("Couldn't copy %q to %q: %w", filename, dir, err)
+	}
+	return target, nil
+}
+
+// Rename renames a file in its directory, and returns its new path. The name
+// can't be a path, and other files are not replaced.
+func Rename(filename, name string) (string, error) {
+	if name == "" || name != filepath.Base(name) {
+		return "", fmt.Errorf("Invalid file name %q", name)
+	}
+	target := filepath.Join(filepath.Dir(filename), name)
+	if err := checkTarget(target); err != nil {
+		return "", err
+	}
+	if err := os.Rename(filename, target); err != nil {
+		return "", fmt.Errorf("Couldn't rename %q: %w", filename, err)
+	}
+	return target, nil
+}
+
+// CheckDir checks that a directory that files are moved or copied to exists.
+func checkDir(dir string) error {
+	stats, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("Couldn't use directory %q: %w", dir, err)
+	}
+	if !stats.IsDir() {
+		return fmt.Errorf("%q is not a directory", dir)
+	}
+	return nil
+}
+
+// CheckTarget checks that nothing exists at the path a file would be moved or
+// copied to.
+func checkTarget(target string) error {
+	if _, err := os.Lstat(target); err == nil {
+		return fmt.Errorf("File %q already exists", target)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Move moves a file. Files on another file system, which can't be renamed,
+// are copied and then removed.
+func move(from, to string) error {
+	if err := os.Rename(from, to); !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyFile(from, to); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
+
+// CopyFile copies a file and its permissions. The copy must not exist yet.
+func copyFile(from, to string) (err error) {
+	in, err := os.Open(from)
+	if err != nil {
+		return
+	}
+	defer in.Close()
+	stats, err := in.Stat()
+	if err != nil {
+		return
+	}
+	out, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, stats.Mode().Perm())
+	if err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(to)
+		}
+	}()
+	_, err = io.Copy(out, in)
+	return
+}
@@ -0,0 +1,149 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// WriteFile writes a file in a directory for a test, and returns its path.
+func writeFile(t *testing.T, dir, name, contents string) string {
+	filename := filepath.Join(dir, name)
+	if err := os.WriteFile(filename, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// TestTrash checks that trashed files would be moved to the trash with info
+// files that record where they came from, without replacing trashed files
+// with the same name.
+func TestTrash(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	now = func() time.Time {
+		return time.Date(2004, 8, 31, 22, 32, 8, 0, time.Local)
+	}
+	defer func() {
+		now = time.Now
+	}()
+	dir := filepath.Join(t.TempDir(), "with space")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, contents := range []string{"first", "second"} {
+		filename := writeFile(t, dir, "image.png", contents)
+		if err := Trash(filename); err != nil {
+			t.Fatalf(`err = %v, want nil`, err)
+		}
+		if _, err := os.Stat(filename); err == nil {
+			t.Errorf(`%q still exists after trashing it`, filename)
+		}
+	}
+
+	trash := filepath.Join(dataHome, "Trash")
+	for name, want := range map[string]string{"image.png": "first", "image.2.png": "second"} {
+		contents, err := os.ReadFile(filepath.Join(trash, "files", name))
+		if err != nil {
+			t.Fatalf(`Couldn't read trashed file: %v`, err)
+		}
+		if string(contents) != want {
+			t.Errorf(`trashed %s = %q, want %q`, name, contents, want)
+		}
+	}
+	info, err := os.ReadFile(filepath.Join(trash, "info", "image.png.trashinfo"))
+	if err != nil {
+		t.Fatalf(`Couldn't read trash info: %v`, err)
+	}
+	want := "[Trash Info]\nPath=" + strings.ReplaceAll(filepath.ToSlash(dir), " ", "%20") + "/image.png\nDeletionDate=2004-08-31T22:32:08\n"
+	if string(info) != want {
+		t.Errorf(`trash info = %q, want %q`, info, want)
+	}
+}
+
+// TestMoveTo checks that a file would be moved into a directory, but not over
+// a file with the same name.
+func TestMoveTo(t *testing.T) {
+	from, to := t.TempDir(), t.TempDir()
+	filename := writeFile(t, from, "image.png", "moved")
+
+	moved, err := MoveTo(filename, to)
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if want := filepath.Join(to, "image.png"); moved != want {
+		t.Errorf(`new path = %q, want %q`, moved, want)
+	}
+	if _, err := os.Stat(filename); err == nil {
+		t.Errorf(`%q still exists after moving it`, filename)
+	}
+
+	filename = writeFile(t, from, "image.png", "other")
+	if _, err := MoveTo(filename, to); err == nil {
+		t.Errorf(`err = nil when the file exists, want an error`)
+	}
+	if contents, _ := os.ReadFile(moved); string(contents) != "moved" {
+		t.Errorf(`contents of the existing file = %q, want "moved"`, contents)
+	}
+}
+
+// TestMoveToMissingDir checks that files wouldn't be moved or copied to
+// directories that don't exist, or to files.
+func TestMoveToMissingDir(t *testing.T) {
+	dir := t.TempDir()
+	filename := writeFile(t, dir, "image.png", "")
+	for _, target := range []string{filepath.Join(dir, "missing"), filename} {
+		if _, err := MoveTo(filename, target); err == nil {
+			t.Errorf(`err = nil when moving to %q, want an error`, target)
+		}
+		if _, err := CopyTo(filename, target); err == nil {
+			t.Errorf(`err = nil when copying to %q, want an error`, target)
+		}
+	}
+	if _, err := os.Stat(filename); err != nil {
+		t.Errorf(`file after failing to move it: %v`, err)
+	}
+}
+
+// TestCopyTo checks that a file would be copied into a directory, keeping the
+// original.
+func TestCopyTo(t *testing.T) {
+	from, to := t.TempDir(), t.TempDir()
+	filename := writeFile(t, from, "image.png", "copied")
+
+	copied, err := CopyTo(filename, to)
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	for _, f := range []string{filename, copied} {
+		if contents, _ := os.ReadFile(f); string(contents) != "copied" {
+			t.Errorf(`contents of %q = %q, want "copied"`, f, contents)
+		}
+	}
+	if _, err := CopyTo(filename, to); err == nil {
+		t.Errorf(`err = nil when the copy exists, want an error`)
+	}
+}
+
+// TestRename checks that a file would be renamed in its directory, and that
+// names that are paths would be an error.
+func TestRename(t *testing.T) {
+	dir := t.TempDir()
+	filename := writeFile(t, dir, "image.png", "")
+
+	renamed, err := Rename(filename, "renamed.png")
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if want := filepath.Join(dir, "renamed.png"); renamed != want {
+		t.Errorf(`new path = %q, want %q`, renamed, want)
+	}
+	for _, name := range []string{"", "../escaped.png", "sub/dir.png"} {
+		if _, err := Rename(renamed, name); err == nil {
+			t.Errorf(`err = nil for %q, want an error`, name)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package files
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SortOrder is the order that files are browsed in.
+type SortOrder int
+
+const (
+	// Unsorted keeps files in the order they were found or given.
+	Unsorted SortOrder = iota
+	// SortByName sorts files by name.
+	SortByName
+	// SortNatural sorts files by name, comparing numbers by their values so
+	// that "2.png" comes before "10.png".
+	SortNatural
+	// SortByModTime sorts files from oldest to newest.
+	SortByModTime
+	// SortBySize sorts files from smallest to largest file size.
+	SortBySize
+)
+
+// Names of sort orders, as used in options.
+var sortOrderNames = map[SortOrder]string{
+	Unsorted:      "none",
+	SortByName:    "name",
+	SortNatural:   "natural",
+	SortByModTime: "mtime",
+	SortBySize:    "size",
+}
+
+// ParseSortOrder parses the name of a sort order.
+func ParseSortOrder(name string) (SortOrder, error) {
+	for order, orderName := range sortOrderNames {
+		if name == orderName {
+			return order, nil
+		}
+	}
+	return Unsorted, fmt.Errorf("Unknown sort order %q, want none, name, natural, mtime or size", name)
+}
+
+// String gets the name of a sort order.
+func (order SortOrder) String() string {
+	return sortOrderNames[order]
+}
+
+// Sort sorts the files, staying on the current file.
+func (browser *FileBrowser) Sort(order SortOrder) error {
+	if order == Unsorted || browser.IsEmpty() {
+		return nil
+	}
+	current := browser.Current()
+	var less func(i, j int) bool
+	filenames := browser.Filenames
+	switch order {
+	case SortByName:
+		less = func(i, j int) bool {
+			return filenames[i] < filenames[j]
+		}
+	case SortNatural:
+		less = func(i, j int) bool {
+			return naturalLess(filenames[i], filenames[j])
+		}
+	case SortByModTime, SortBySize:
+		stats := make(map[string]os.FileInfo, len(filenames))
+		for _, filename := range filenames {
+			fileStats, err := osStat(filename)
+			if err != nil {
+				return err
+			}
+			stats[filename] = fileStats
+		}
+		less = func(i, j int) bool {
+			a, b := stats[filenames[i]], stats[filenames[j]]
+			if order == SortBySize {
+				return a.Size() < b.Size()
+			}
+			return a.ModTime().Before(b.ModTime())
+		}
+	}
+	sort.SliceStable(filenames, less)
+	browser.Select(current)
+	return nil
+}
+
+// Shuffle shuffles the files, staying on the current file. Files are always
+// shuffled in the same order for the same seed.
+func (browser *FileBrowser) Shuffle(seed int64) {
+	if browser.IsEmpty() {
+		return
+	}
+	current := browser.Current()
+	random := rand.New(rand.NewSource(seed))
+	random.Shuffle(len(browser.Filenames), func(i, j int) {
+		browser.Filenames[i], browser.Filenames[j] = browser.Filenames[j], browser.Filenames[i]
+	})
+	browser.Select(current)
+}
+
+// Select moves to a file. It returns false, without moving, if the file isn't
+// browsed.
+func (browser *FileBrowser) Select(filename string) bool {
+	for i, f := range browser.Filenames {
+		if f == filename {
+			browser.index = i
+			return true
+		}
+	}
+	return false
+}
+
+// NaturalLess compares strings, comparing runs of digits by their numeric
+// values.
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		aDigits, bDigits := leadingDigits(a), leadingDigits(b)
+		if aDigits != "" && bDigits != "" {
+			aTrimmed := strings.TrimLeft(aDigits, "0")
+			bTrimmed := strings.TrimLeft(bDigits, "0")
+			if len(aTrimmed) != len(bTrimmed) {
+				return len(aTrimmed) < len(bTrimmed)
+			}
+			if aTrimmed != bTrimmed {
+				return aTrimmed < bTrimmed
+			}
+			a, b = a[len(aDigits):], b[len(bDigits):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// LeadingDigits gets the digits at the start of a string.
+func leadingDigits(s string) string {
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r) || r > unicode.MaxASCII
+	})
+	if end < 0 {
+		return s
+	}
+	return s[:end]
+}
@@ -0,0 +1,100 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestParseSortOrder checks that sort orders can be parsed from their names.
+func TestParseSortOrder(t *testing.T) {
+	for _, order := range []SortOrder{Unsorted, SortByName, SortNatural, SortByModTime, SortBySize} {
+		actual, err := ParseSortOrder(order.String())
+		if err != nil {
+			t.Fatalf(`err = %v, want nil`, err)
+		}
+		if actual != order {
+			t.Errorf(`ParseSortOrder(%q) = %v, want %v`, order.String(), actual, order)
+		}
+	}
+	if _, err := ParseSortOrder("random"); err == nil {
+		t.Errorf(`err = nil`)
+	}
+}
+
+// TestSortNatural checks that numbers would be sorted by their values, and
+// that the browser stays on the current file.
+func TestSortNatural(t *testing.T) {
+	fb := FileBrowser{0, []string{"img10.png", "img2.png", "img1.png", "img02b.png"}}
+
+	if err := fb.Sort(SortNatural); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	want := []string{"img1.png", "img2.png", "img02b.png", "img10.png"}
+	for i, v := range want {
+		if fb.Filenames[i] != v {
+			t.Errorf(`Filenames[%d] = %q, want %q`, i, fb.Filenames[i], v)
+		}
+	}
+	if actual := fb.Current(); actual != "img10.png" {
+		t.Errorf(`Current() = %q, want "img10.png"`, actual)
+	}
+}
+
+// TestSortByModTime checks that files would be sorted from oldest to newest.
+func TestSortByModTime(t *testing.T) {
+	tempDir := t.TempDir()
+	now := time.Now()
+	var filenames []string
+	for i, name := range []string{"new.png", "old.png"} {
+		filename := filepath.Join(tempDir, name)
+		if err := os.WriteFile(filename, nil, 0600); err != nil {
+			panic(err)
+		}
+		modTime := now.Add(-time.Duration(i) * time.Hour)
+		if err := os.Chtimes(filename, modTime, modTime); err != nil {
+			panic(err)
+		}
+		filenames = append(filenames, filename)
+	}
+	fb := FileBrowser{Filenames: filenames}
+
+	if err := fb.Sort(SortByModTime); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	if actual := filepath.Base(fb.Filenames[0]); actual != "old.png" {
+		t.Errorf(`first file = %q, want "old.png"`, actual)
+	}
+}
+
+// TestShuffle checks that shuffling would keep the same files in the same
+// order for a seed, and stay on the current file.
+func TestShuffle(t *testing.T) {
+	filenames := []string{"a.png", "b.png", "c.png", "d.png", "e.png", "f.png"}
+	shuffle := func(seed int64) FileBrowser {
+		fb := FileBrowser{2, append([]string(nil), filenames...)}
+		fb.Shuffle(seed)
+		return fb
+	}
+	fb := shuffle(42)
+
+	if actual := fb.Current(); actual != "c.png" {
+		t.Errorf(`current file = %q, want "c.png"`, actual)
+	}
+	again := shuffle(42)
+	for i := range fb.Filenames {
+		if fb.Filenames[i] != again.Filenames[i] {
+			t.Errorf(`Filenames[%d] = %q with the same seed, want %q`, i, again.Filenames[i], fb.Filenames[i])
+		}
+	}
+	seen := make(map[string]bool)
+	for _, filename := range fb.Filenames {
+		seen[filename] = true
+	}
+	if len(seen) != len(filenames) {
+		t.Errorf(`shuffled files = %v, want each of %v once`, fb.Filenames, filenames)
+	}
+}
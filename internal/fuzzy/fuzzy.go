@@ -0,0 +1,78 @@
+// Package fuzzy implements fuzzy matching of strings, so that "dfpng" matches
+// "a_diff.png".
+package fuzzy
+
+import (
+	"sort"
+	"unicode"
+)
+
+// Bonuses added to the score of a match.
+const (
+	matchBonus       = 1
+	consecutiveBonus = 5
+	wordStartBonus   = 3
+)
+
+// Score checks if all runes of the pattern appear in s in order, ignoring case.
+// Higher scores mean better matches. Consecutive runes and runes at the start
+// of words score higher.
+func Score(pattern, s string) (score int, ok bool) {
+	patternRunes := []rune(pattern)
+	if len(patternRunes) == 0 {
+		return 0, true
+	}
+	i := 0
+	prevMatched := false
+	prev := rune(0)
+	for j, r := range []rune(s) {
+		if i < len(patternRunes) && unicode.ToLower(r) == unicode.ToLower(patternRunes[i]) {
+			score += matchBonus
+			if prevMatched {
+				score += consecutiveBonus
+			}
+			if j == 0 || isWordStart(prev, r) {
+				score += wordStartBonus
+			}
+			i++
+			prevMatched = true
+		} else {
+			prevMatched = false
+		}
+		prev = r
+	}
+	if i < len(patternRunes) {
+		return 0, false
+	}
+	return score, true
+}
+
+// Filter returns the indices of the candidates that match the pattern, from
+// best to worst match. Candidates that match equally well keep their order.
+func Filter(pattern string, candidates []string) []int {
+	type match struct {
+		index, score int
+	}
+	matches := make([]match, 0, len(candidates))
+	for i, candidate := range candidates {
+		if score, ok := Score(pattern, candidate); ok {
+			matches = append(matches, match{i, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	indices := make([]int, len(matches))
+	for i, m := range matches {
+		indices[i] = m.index
+	}
+	return indices
+}
+
+// IsWordStart checks if a rune starts a new word, given the previous rune.
+func isWordStart(prev, r rune) bool {
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return true
+	}
+	return unicode.IsUpper(r) && unicode.IsLower(prev)
+}
@@ -0,0 +1,49 @@
+package fuzzy
+
+import "testing"
+
+// TestScoreMatches checks that a pattern would match strings that contain its
+// runes in order, ignoring case.
+func TestScoreMatches(t *testing.T) {
+	for _, s := range []string{"a_diff.png", "A_DIFF.PNG", "diffpng"} {
+		if _, ok := Score("dfpng", s); !ok {
+			t.Errorf(`"dfpng" did not match %q`, s)
+		}
+	}
+	for _, s := range []string{"png_diff", "diff.jpg", ""} {
+		if _, ok := Score("dfpng", s); ok {
+			t.Errorf(`"dfpng" matched %q`, s)
+		}
+	}
+}
+
+// TestEmptyPattern checks that an empty pattern would match anything.
+func TestEmptyPattern(t *testing.T) {
+	if _, ok := Score("", "anything"); !ok {
+		t.Errorf(`empty pattern did not match`)
+	}
+}
+
+// TestFilterOrder checks that better matches would be ordered first, and that
+// equal matches keep their order.
+func TestFilterOrder(t *testing.T) {
+	candidates := []string{
+		"cat.png",
+		"a_cat.png",
+		"cxaxt.png",
+		"dog.png",
+		"b_cat.png",
+	}
+
+	actual := Filter("cat", candidates)
+
+	want := []int{0, 1, 4, 2}
+	if len(actual) != len(want) {
+		t.Fatalf(`Filter = %v, want %v`, actual, want)
+	}
+	for i, v := range want {
+		if actual[i] != v {
+			t.Errorf(`Filter[%d] = %d, want %d`, i, actual[i], v)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+// Package keys maps key presses to named actions of the viewer.
+package keys
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Action is the name of something the viewer can do, like "zoom-in".
+type Action string
+
+// Actions of the viewer.
+const (
+	Quit                   Action = "quit"
+	Next                   Action = "next"
+	Previous               Action = "previous"
+	First                  Action = "first"
+	Last                   Action = "last"
+	Search                 Action = "search"
+	NextMatch              Action = "next-match"
+	PreviousMatch          Action = "previous-match"
+	ZoomIn                 Action = "zoom-in"
+	ZoomOut                Action = "zoom-out"
+	Fit                    Action = "fit"
+	FitWidth               Action = "fit-width"
+	FitHeight              Action = "fit-height"
+	ActualSize             Action = "actual-size"
+	NextFilter             Action = "next-filter"
+	ToggleStatus           Action = "toggle-status"
+	ToggleEXIF             Action = "toggle-exif"
+	ToggleErrors           Action = "toggle-errors"
+	ToggleSlideshow        Action = "toggle-slideshow"
+	ToggleShuffle          Action = "toggle-shuffle"
+	Random                 Action = "random"
+	ToggleMark             Action = "toggle-mark"
+	Tag                    Action = "tag"
+	Trash                  Action = "trash"
+	MoveFile               Action = "move"
+	CopyFile               Action = "copy"
+	RenameFile             Action = "rename"
+	RotateClockwise        Action = "rotate-clockwise"
+	RotateCounterClockwise Action = "rotate-counterclockwise"
+	FlipHorizontal         Action = "flip-horizontal"
+	FlipVertical           Action = "flip-vertical"
+	ScrollLeft             Action = "scroll-left"
+	ScrollLeftFast         Action = "scroll-left-fast"
+	ScrollDown             Action = "scroll-down"
+	ScrollDownFast         Action = "scroll-down-fast"
+	ScrollUp               Action = "scroll-up"
+	ScrollUpFast           Action = "scroll-up-fast"
+	ScrollRight            Action = "scroll-right"
+	ScrollRightFast        Action = "scroll-right-fast"
+)
+
+// ActionInfo describes an action and its default keys.
+type ActionInfo struct {
+	Action      Action
+	Description string
+	Defaults    []string
+}
+
+// Registry contains all actions, in the order they should be listed.
+var Registry = []ActionInfo{
+	{Next, "Next image", []string{"n"}},
+	{Previous, "Previous image", []string{"N"}},
+	{First, "First image, or image number [count]", []string{"g"}},
+	{Last, "Last image, or image number [count]", []string{"G"}},
+	{Random, "Random image", []string{"x"}},
+	{ToggleShuffle, "Shuffle the images, or restore their order", []string{"S"}},
+	{Search, "Search for an image by name", []string{"/"}},
+	{NextMatch, "Next image matching the last search", []string{"."}},
+	{PreviousMatch, "Previous image matching the last search", []string{","}},
+	{ZoomIn, "Zoom in", []string{"z"}},
+	{ZoomOut, "Zoom out", []string{"Z"}},
+	{Fit, "Fit to screen", []string{"f"}},
+	{FitWidth, "Fit width to screen", []string{"w"}},
+	{FitHeight, "Fit height to screen", []string{"W"}},
+	{ActualSize, "Actual size, one pixel per cell row", []string{"="}},
+	{NextFilter, "Next resampling filter", []string{"F"}},
+	{ToggleStatus, "Show or hide the status bar", []string{"i"}},
+	{ToggleEXIF, "Show or hide EXIF metadata", []string{"e"}},
+	{ToggleErrors, "Show or hide the files that failed to load", []string{"E"}},
+	{ToggleSlideshow, "Start or stop the slideshow", []string{"s"}},
+	{ToggleMark, "Mark or unmark the image", []string{"Space"}},
+	{Tag, "Add a tag to the image, or remove it", []string{"t"}},
+	{Trash, "Move the image to the trash", []string{"d", "Delete"}},
+	{MoveFile, "Move the image to a directory", []string{"v"}},
+	{CopyFile, "Copy the image to a directory", []string{"c"}},
+	{RenameFile, "Rename the image", []string{"F2"}},
+	{RotateClockwise, "Rotate clockwise", []string{"r"}},
+	{RotateCounterClockwise, "Rotate counter-clockwise", []string{"R"}},
+	{FlipHorizontal, "Flip horizontally", []string{"m"}},
+	{FlipVertical, "Flip vertically", []string{"M"}},
+	{ScrollLeft, "Scroll left one pixel", []string{"h"}},
+	{ScrollLeftFast, "Scroll left by the scroll step (10% by default)", []string{"H"}},
+	{ScrollDown, "Scroll down one pixel", []string{"j"}},
+	{ScrollDownFast, "Scroll down by the scroll step (10% by default)", []string{"J"}},
+	{ScrollUp, "Scroll up one pixel", []string{"k"}},
+	{ScrollUpFast, "Scroll up by the scroll step (10% by default)", []string{"K"}},
+	{ScrollRight, "Scroll right one pixel", []string{"l"}},
+	{ScrollRightFast, "Scroll right by the scroll step (10% by default)", []string{"L"}},
+	{Quit, "Exit application", []string{"Esc"}},
+}
+
+// Key is a key press, possibly with modifiers.
+type Key struct {
+	Key  tcell.Key
+	Rune rune
+	Mod  tcell.ModMask
+}
+
+// Modifiers that are significant when comparing keys. Shift is not
+// significant for runes, as it is already part of the rune.
+const significantMods = tcell.ModCtrl | tcell.ModAlt | tcell.ModMeta
+
+// Names of modifiers, in the order they are written.
+var modNames = []struct {
+	mod  tcell.ModMask
+	name string
+}{
+	{tcell.ModCtrl, "Ctrl"},
+	{tcell.ModAlt, "Alt"},
+	{tcell.ModMeta, "Meta"},
+	{tcell.ModShift, "Shift"},
+}
+
+// KeyFromEvent converts a key event to a Key.
+func KeyFromEvent(ev *tcell.EventKey) Key {
+	return normalize(Key{ev.Key(), ev.Rune(), ev.Modifiers()})
+}
+
+// ParseKey parses a key like "n", "Esc", "Ctrl+N", "Alt+h" or "Shift+Left".
+func ParseKey(s string) (Key, error) {
+	var mod tcell.ModMask
+	name := s
+	for {
+		i := strings.IndexAny(name, "+-")
+		if i <= 0 || i == len(name)-1 {
+			break
+		}
+		found := false
+		for _, m := range modNames {
+			if strings.EqualFold(name[:i], m.name) {
+				mod |= m.mod
+				found = true
+			}
+		}
+		if !found {
+			break
+		}
+		name = name[i+1:]
+	}
+
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		if mod&tcell.ModCtrl != 0 && r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		if mod&tcell.ModCtrl != 0 && r >= 'A' && r <= 'Z' {
+			return normalize(Key{tcell.KeyCtrlA + tcell.Key(r-'A'), 0, mod}), nil
+		}
+		return normalize(Key{tcell.KeyRune, r, mod}), nil
+	}
+	if strings.EqualFold(name, "Space") {
+		return normalize(Key{tcell.KeyRune, ' ', mod}), nil
+	}
+	for k, keyName := range tcell.KeyNames {
+		if strings.EqualFold(name, keyName) {
+			return normalize(Key{k, 0, mod}), nil
+		}
+	}
+	return Key{}, fmt.Errorf("Unknown key %q", s)
+}
+
+// String formats the key so that ParseKey would parse it.
+func (k Key) String() string {
+	var b strings.Builder
+	mod := k.Mod
+	name := ""
+	switch {
+	case k.Key == tcell.KeyRune && k.Rune == ' ':
+		name = "Space"
+	case k.Key == tcell.KeyRune:
+		name = string(k.Rune)
+	case k.Key >= tcell.KeyCtrlA && k.Key <= tcell.KeyCtrlZ && !isTypeable(k.Key):
+		mod |= tcell.ModCtrl
+		name = string(rune('A' + k.Key - tcell.KeyCtrlA))
+	default:
+		var ok bool
+		if name, ok = tcell.KeyNames[k.Key]; !ok {
+			name = fmt.Sprintf("Key[%d]", k.Key)
+		}
+	}
+	for _, m := range modNames {
+		if mod&m.mod != 0 {
+			b.WriteString(m.name)
+			b.WriteByte('+')
+		}
+	}
+	b.WriteString(name)
+	return b.String()
+}
+
+// IsPlainRune checks if the key is a rune without modifiers.
+func (k Key) isPlainRune() bool {
+	return k.Key == tcell.KeyRune && k.Mod == tcell.ModNone
+}
+
+// Normalize removes information that shouldn't be compared between keys.
+func normalize(k Key) Key {
+	switch {
+	case k.Key == tcell.KeyRune:
+		k.Mod &= significantMods
+	case k.Key >= tcell.KeyCtrlA && k.Key <= tcell.KeyCtrlZ:
+		// NOTE Control keys are their own key codes, so Ctrl is redundant
+		k.Rune = 0
+		k.Mod &= significantMods &^ tcell.ModCtrl
+	default:
+		k.Rune = 0
+	}
+	return k
+}
+
+// IsTypeable checks if a control key can be typed without holding Ctrl, like
+// Tab or Enter.
+func isTypeable(k tcell.Key) bool {
+	switch k {
+	case tcell.KeyBackspace, tcell.KeyTab, tcell.KeyEnter:
+		return true
+	}
+	return false
+}
+
+// Bindings maps keys to actions.
+type Bindings map[Key]Action
+
+// DefaultBindings gets the default bindings of all actions.
+func DefaultBindings() Bindings {
+	b := make(Bindings)
+	for _, info := range Registry {
+		for _, s := range info.Defaults {
+			k, err := ParseKey(s)
+			if err != nil {
+				panic(err)
+			}
+			b[k] = info.Action
+		}
+	}
+	return b
+}
+
+// Rebind replaces all keys of an action.
+func (b Bindings) Rebind(action Action, keys []string) error {
+	if !IsAction(action) {
+		return fmt.Errorf("Unknown action %q", action)
+	}
+	parsed := make([]Key, 0, len(keys))
+	for _, s := range keys {
+		k, err := ParseKey(s)
+		if err != nil {
+			return fmt.Errorf("Couldn't bind %q: %w", action, err)
+		}
+		parsed = append(parsed, k)
+	}
+	for k, a := range b {
+		if a == action {
+			delete(b, k)
+		}
+	}
+	for _, k := range parsed {
+		b[k] = action
+	}
+	return nil
+}
+
+// Action gets the action bound to a key event.
+func (b Bindings) Action(ev *tcell.EventKey) (Action, bool) {
+	action, ok := b[KeyFromEvent(ev)]
+	return action, ok
+}
+
+// Keys gets the keys bound to an action. Plain runes are sorted before other
+// keys, and keys are otherwise sorted by their names.
+func (b Bindings) Keys(action Action) []Key {
+	var keys []Key
+	for k, a := range b {
+		if a == action {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		iRune, jRune := keys[i].isPlainRune(), keys[j].isPlainRune()
+		if iRune != jRune {
+			return iRune
+		}
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
+// IsAction checks if an action exists in the registry.
+func IsAction(action Action) bool {
+	for _, info := range Registry {
+		if info.Action == action {
+			return true
+		}
+	}
+	return false
+}
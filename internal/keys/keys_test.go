@@ -0,0 +1,101 @@
+package keys
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// TestParseKey checks that keys would be parsed with modifiers and special
+// key names.
+func TestParseKey(t *testing.T) {
+	strToKey := map[string]Key{
+		"n":          {tcell.KeyRune, 'n', tcell.ModNone},
+		"N":          {tcell.KeyRune, 'N', tcell.ModNone},
+		"+":          {tcell.KeyRune, '+', tcell.ModNone},
+		"Space":      {tcell.KeyRune, ' ', tcell.ModNone},
+		"Alt+h":      {tcell.KeyRune, 'h', tcell.ModAlt},
+		"alt-+":      {tcell.KeyRune, '+', tcell.ModAlt},
+		"Esc":        {tcell.KeyEsc, 0, tcell.ModNone},
+		"Ctrl+n":     {tcell.KeyCtrlN, 0, tcell.ModNone},
+		"Ctrl-N":     {tcell.KeyCtrlN, 0, tcell.ModNone},
+		"Shift+Left": {tcell.KeyLeft, 0, tcell.ModShift},
+		"pgdn":       {tcell.KeyPgDn, 0, tcell.ModNone},
+	}
+	for s, want := range strToKey {
+		actual, err := ParseKey(s)
+		if err != nil {
+			t.Fatalf(`ParseKey(%q) err = %v, want nil`, s, err)
+		}
+		if actual != want {
+			t.Errorf(`ParseKey(%q) = %#v, want %#v`, s, actual, want)
+		}
+	}
+	for _, s := range []string{"", "Nope", "Hyper+n"} {
+		if _, err := ParseKey(s); err == nil {
+			t.Errorf(`ParseKey(%q) err = nil`, s)
+		}
+	}
+}
+
+// TestKeyString checks that keys would be formatted so that they can be parsed
+// again.
+func TestKeyString(t *testing.T) {
+	for _, s := range []string{"n", "Space", "Alt+h", "Esc", "Ctrl+N", "Shift+Left", "Tab"} {
+		k, err := ParseKey(s)
+		if err != nil {
+			t.Fatalf(`ParseKey(%q) err = %v, want nil`, s, err)
+		}
+		if actual := k.String(); actual != s {
+			t.Errorf(`String() = %q, want %q`, actual, s)
+		}
+	}
+}
+
+// TestBindingsAction checks that key events would be matched to actions,
+// ignoring insignificant modifiers.
+func TestBindingsAction(t *testing.T) {
+	b := DefaultBindings()
+	for ev, want := range map[*tcell.EventKey]Action{
+		tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone):  Next,
+		tcell.NewEventKey(tcell.KeyRune, 'N', tcell.ModShift): Previous,
+		tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone):  Quit,
+	} {
+		actual, ok := b.Action(ev)
+		if !ok || actual != want {
+			t.Errorf(`Action(%v) = %q, want %q`, ev.Name(), actual, want)
+		}
+	}
+	if actual, ok := b.Action(tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModAlt)); ok {
+		t.Errorf(`Alt+n is bound to %q`, actual)
+	}
+}
+
+// TestRebind checks that rebinding would replace the default keys of an
+// action.
+func TestRebind(t *testing.T) {
+	b := DefaultBindings()
+
+	if err := b.Rebind(Next, []string{"Right", "Ctrl+N"}); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	keys := b.Keys(Next)
+	if len(keys) != 2 || keys[0].String() != "Ctrl+N" || keys[1].String() != "Right" {
+		t.Errorf(`Keys(Next) = %v, want [Ctrl+N Right]`, keys)
+	}
+	if _, ok := b.Action(tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone)); ok {
+		t.Errorf(`default key is still bound`)
+	}
+}
+
+// TestRebindErrors checks that unknown actions and keys are errors.
+func TestRebindErrors(t *testing.T) {
+	b := DefaultBindings()
+	if err := b.Rebind("fly", []string{"f"}); err == nil {
+		t.Errorf(`unknown action err = nil`)
+	}
+	if err := b.Rebind(Next, []string{"Nope"}); err == nil {
+		t.Errorf(`unknown key err = nil`)
+	}
+}
@@ -0,0 +1,241 @@
+// Package prefetch decodes images in the background so that they are ready
+// before they are viewed.
+package prefetch
+
+import (
+	"container/list"
+	"context"
+	"image"
+	"sync"
+
+	"github.com/spenserblack/termage/internal/utils"
+	"github.com/spenserblack/termage/pkg/gif"
+)
+
+// DefaultBudget is the default number of bytes that decoded images may use.
+const DefaultBudget int64 = 256 << 20
+
+// Workers is the maximum number of images that are decoded at the same time
+// in the background.
+const workers = 2
+
+// Loader loads an image, its title and the metadata of its file.
+type Loader func(filename string) (m image.Image, title string, meta utils.Metadata, err error)
+
+// Entry is the result of loading an image.
+type Entry struct {
+	Image    image.Image
+	Title    string
+	Metadata utils.Metadata
+	Err      error
+	// Prepared is the result of Prefetcher.Prepare, if it is set.
+	Prepared interface{}
+}
+
+// Prefetcher loads images and keeps them in memory until the memory budget is
+// exceeded, dropping the least recently used images first.
+type Prefetcher struct {
+	// Prepare is optionally called on each successfully loaded image in the
+	// goroutine that loaded it, to do expensive work such as pre-scaling
+	// ahead of time.
+	Prepare func(image.Image) interface{}
+
+	load    Loader
+	budget  int64
+	used    int64
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	pending map[string]chan struct{}
+	cancel  context.CancelFunc
+	sem     chan struct{}
+}
+
+// Cached is an entry in the LRU list.
+type cached struct {
+	filename string
+	entry    Entry
+	size     int64
+}
+
+// New creates a prefetcher that loads images with a Loader and keeps at most
+// budget bytes of decoded images.
+func New(load Loader, budget int64) *Prefetcher {
+	return &Prefetcher{
+		load:    load,
+		budget:  budget,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		pending: make(map[string]chan struct{}),
+		cancel:  func() {},
+		sem:     make(chan struct{}, workers),
+	}
+}
+
+// Get returns the loaded image for a filename. If the image has not been
+// prefetched, it is loaded immediately. If it is currently being prefetched,
+// Get waits for it.
+func (p *Prefetcher) Get(filename string) Entry {
+	p.mu.Lock()
+	for {
+		if e, ok := p.take(filename); ok {
+			p.mu.Unlock()
+			return e
+		}
+		done, ok := p.pending[filename]
+		if !ok {
+			break
+		}
+		p.mu.Unlock()
+		<-done
+		p.mu.Lock()
+	}
+	done := make(chan struct{})
+	p.pending[filename] = done
+	p.mu.Unlock()
+
+	e := p.loadEntry(context.Background(), filename)
+
+	p.mu.Lock()
+	delete(p.pending, filename)
+	close(done)
+	if _, animated := e.Image.(*gif.Helper); !animated {
+		p.store(filename, e)
+	}
+	p.mu.Unlock()
+	return e
+}
+
+// Has checks if an image has already been loaded.
+func (p *Prefetcher) Has(filename string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.entries[filename]
+	return ok
+}
+
+// Prefetch loads images in the background. Prefetches started by previous
+// calls that have not started decoding yet are cancelled.
+func (p *Prefetcher) Prefetch(filenames ...string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.mu.Lock()
+	p.cancel()
+	p.cancel = cancel
+	p.mu.Unlock()
+
+	for _, filename := range filenames {
+		go p.prefetch(ctx, filename)
+	}
+}
+
+// Stop cancels all pending prefetches.
+func (p *Prefetcher) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cancel()
+}
+
+// Prefetch loads a single image in the background, unless the context is
+// cancelled before a worker is available. Images that finish loading after
+// the context is cancelled are dropped.
+func (p *Prefetcher) prefetch(ctx context.Context, filename string) {
+	select {
+	case p.sem <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	defer func() {
+		<-p.sem
+	}()
+
+	p.mu.Lock()
+	if ctx.Err() != nil {
+		p.mu.Unlock()
+		return
+	}
+	if _, ok := p.entries[filename]; ok {
+		p.mu.Unlock()
+		return
+	}
+	if _, ok := p.pending[filename]; ok {
+		p.mu.Unlock()
+		return
+	}
+	done := make(chan struct{})
+	p.pending[filename] = done
+	p.mu.Unlock()
+
+	e := p.loadEntry(ctx, filename)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pending, filename)
+	close(done)
+	if ctx.Err() != nil {
+		return
+	}
+	p.store(filename, e)
+}
+
+// LoadEntry loads and prepares an image. Images aren't prepared once the
+// context is cancelled.
+func (p *Prefetcher) loadEntry(ctx context.Context, filename string) Entry {
+	m, title, meta, err := p.load(filename)
+	e := Entry{Image: m, Title: title, Metadata: meta, Err: err}
+	if m != nil && p.Prepare != nil && ctx.Err() == nil {
+		e.Prepared = p.Prepare(m)
+	}
+	return e
+}
+
+// Take gets a stored entry and marks it as recently used. Animated GIFs
+// carry their animation state, so they are removed from the cache rather than
+// being shared. Must be called with the lock held.
+func (p *Prefetcher) take(filename string) (Entry, bool) {
+	el, ok := p.entries[filename]
+	if !ok {
+		return Entry{}, false
+	}
+	c := el.Value.(*cached)
+	if _, animated := c.entry.Image.(*gif.Helper); animated {
+		p.remove(el)
+	} else {
+		p.lru.MoveToFront(el)
+	}
+	return c.entry, true
+}
+
+// Store adds an entry and evicts the least recently used entries until the
+// budget is no longer exceeded. The newest entry is always kept. Must be
+// called with the lock held.
+func (p *Prefetcher) store(filename string, e Entry) {
+	if el, ok := p.entries[filename]; ok {
+		p.remove(el)
+	}
+	c := &cached{filename, e, imageSize(e.Image)}
+	p.entries[filename] = p.lru.PushFront(c)
+	p.used += c.size
+	for p.used > p.budget && p.lru.Len() > 1 {
+		p.remove(p.lru.Back())
+	}
+}
+
+// Remove removes an entry. Must be called with the lock held.
+func (p *Prefetcher) remove(el *list.Element) {
+	c := p.lru.Remove(el).(*cached)
+	delete(p.entries, c.filename)
+	p.used -= c.size
+}
+
+// ImageSize estimates the memory used by a decoded image.
+func imageSize(m image.Image) int64 {
+	if m == nil {
+		return 0
+	}
+	size := m.Bounds().Size()
+	bytes := int64(size.X) * int64(size.Y) * 4
+	if g, ok := m.(*gif.Helper); ok {
+		bytes *= int64(len(g.Frames))
+	}
+	return bytes
+}
@@ -0,0 +1,196 @@
+package prefetch
+
+import (
+	"image"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spenserblack/termage/internal/utils"
+	"github.com/spenserblack/termage/pkg/gif"
+)
+
+// CountingLoader is a loader that counts how many times each file was loaded.
+type countingLoader struct {
+	mu    sync.Mutex
+	loads map[string]int
+	size  int
+	image func(size int) image.Image
+}
+
+func newCountingLoader(size int) *countingLoader {
+	return &countingLoader{
+		loads: make(map[string]int),
+		size:  size,
+		image: func(size int) image.Image {
+			return image.NewRGBA(image.Rect(0, 0, size, size))
+		},
+	}
+}
+
+func (l *countingLoader) load(filename string) (image.Image, string, utils.Metadata, error) {
+	l.mu.Lock()
+	l.loads[filename]++
+	l.mu.Unlock()
+	return l.image(l.size), filename, utils.Metadata{Format: "png"}, nil
+}
+
+func (l *countingLoader) count(filename string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.loads[filename]
+}
+
+// WaitFor waits until a file has been loaded by the prefetcher.
+func waitFor(t *testing.T, p *Prefetcher, filename string) {
+	deadline := time.Now().Add(time.Second)
+	for !p.Has(filename) {
+		if time.Now().After(deadline) {
+			t.Fatalf(`%q was never prefetched`, filename)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestGetCaches checks that an image is only loaded once.
+func TestGetCaches(t *testing.T) {
+	l := newCountingLoader(1)
+	p := New(l.load, DefaultBudget)
+
+	p.Get("a.png")
+	e := p.Get("a.png")
+
+	if e.Title != "a.png" {
+		t.Errorf(`Title = %q, want %q`, e.Title, "a.png")
+	}
+	if actual := l.count("a.png"); actual != 1 {
+		t.Errorf(`loaded %d times, want 1`, actual)
+	}
+}
+
+// TestPrefetch checks that a prefetched image would not be loaded again.
+func TestPrefetch(t *testing.T) {
+	l := newCountingLoader(1)
+	p := New(l.load, DefaultBudget)
+
+	p.Prefetch("a.png", "b.png")
+	waitFor(t, p, "a.png")
+	waitFor(t, p, "b.png")
+	p.Get("a.png")
+	p.Get("b.png")
+
+	for _, filename := range []string{"a.png", "b.png"} {
+		if actual := l.count(filename); actual != 1 {
+			t.Errorf(`%q loaded %d times, want 1`, filename, actual)
+		}
+	}
+}
+
+// TestPrepare checks that Prepare would be called on loaded images.
+func TestPrepare(t *testing.T) {
+	l := newCountingLoader(2)
+	p := New(l.load, DefaultBudget)
+	p.Prepare = func(m image.Image) interface{} {
+		return m.Bounds().Dx()
+	}
+
+	e := p.Get("a.png")
+
+	if e.Prepared != 2 {
+		t.Errorf(`Prepared = %v, want 2`, e.Prepared)
+	}
+}
+
+// TestEviction checks that the least recently used images would be evicted
+// when the budget is exceeded.
+func TestEviction(t *testing.T) {
+	l := newCountingLoader(2)
+	// NOTE Each image uses 2 * 2 * 4 bytes
+	p := New(l.load, 32)
+
+	p.Get("a.png")
+	p.Get("b.png")
+	p.Get("a.png")
+	p.Get("c.png")
+
+	if !p.Has("a.png") {
+		t.Errorf(`recently used image was evicted`)
+	}
+	if p.Has("b.png") {
+		t.Errorf(`least recently used image was not evicted`)
+	}
+	if !p.Has("c.png") {
+		t.Errorf(`newest image was evicted`)
+	}
+}
+
+// TestAnimatedNotShared checks that an animated GIF would not be shared
+// between viewings, as it contains animation state.
+func TestAnimatedNotShared(t *testing.T) {
+	l := newCountingLoader(1)
+	l.image = func(size int) image.Image {
+		return &gif.Helper{Frames: []gif.Frame{{Image: image.NewRGBA(image.Rect(0, 0, size, size))}}}
+	}
+	p := New(l.load, DefaultBudget)
+
+	p.Get("a.gif")
+	p.Get("a.gif")
+
+	if actual := l.count("a.gif"); actual != 2 {
+		t.Errorf(`loaded %d times, want 2`, actual)
+	}
+}
+
+// TestStop checks that prefetches that haven't started would be cancelled.
+func TestStop(t *testing.T) {
+	l := newCountingLoader(1)
+	p := New(l.load, DefaultBudget)
+	for i := 0; i < workers; i++ {
+		p.sem <- struct{}{}
+	}
+
+	p.Prefetch("a.png")
+	p.Stop()
+	for i := 0; i < workers; i++ {
+		<-p.sem
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if actual := l.count("a.png"); actual != 0 {
+		t.Errorf(`loaded %d times, want 0`, actual)
+	}
+}
+
+// TestStopWhileLoading checks that an image that was loading when the
+// prefetches were cancelled would be neither prepared nor kept.
+func TestStopWhileLoading(t *testing.T) {
+	l := newCountingLoader(1)
+	started, release := make(chan struct{}), make(chan struct{})
+	l.image = func(size int) image.Image {
+		close(started)
+		<-release
+		return image.NewRGBA(image.Rect(0, 0, size, size))
+	}
+	p := New(l.load, DefaultBudget)
+	prepared := false
+	p.Prepare = func(image.Image) interface{} {
+		prepared = true
+		return nil
+	}
+
+	p.Prefetch("a.png")
+	<-started
+	p.Stop()
+	close(release)
+	// NOTE Waits for the worker to finish
+	for i := 0; i < workers; i++ {
+		p.sem <- struct{}{}
+	}
+
+	if p.Has("a.png") {
+		t.Errorf(`image was kept after the prefetch was cancelled`)
+	}
+	if prepared {
+		t.Errorf(`image was prepared after the prefetch was cancelled`)
+	}
+}
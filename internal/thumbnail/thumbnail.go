@@ -0,0 +1,248 @@
+// Package thumbnail implements a persistent thumbnail cache following the
+// freedesktop.org thumbnail managing standard.
+//
+// Thumbnails are stored as PNGs named after the MD5 hash of the original
+// file's URI, and carry the original file's URI and modification time so that
+// stale thumbnails can be detected.
+package thumbnail
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+)
+
+// Size is the maximum width and height of a thumbnail.
+type Size int
+
+// Thumbnail sizes defined by the standard.
+const (
+	Normal  Size = 128
+	Large   Size = 256
+	XLarge  Size = 512
+	XXLarge Size = 1024
+)
+
+// Keys of the PNG text chunks required by the standard.
+const (
+	uriKey   = "Thumb::URI"
+	mtimeKey = "Thumb::MTime"
+)
+
+// ErrNotCached signifies that there is no up-to-date thumbnail for a file.
+var ErrNotCached = errors.New("Thumbnail is not cached")
+
+// For mocking functions.
+var (
+	getenv      = os.Getenv
+	userHomeDir = os.UserHomeDir
+	absPath     = filepath.Abs
+	osStat      = os.Stat
+)
+
+// Cache is a thumbnail cache rooted at a directory, which is usually
+// $XDG_CACHE_HOME/thumbnails.
+type Cache struct {
+	Dir string
+}
+
+// DefaultCache returns the thumbnail cache of the current user.
+func DefaultCache() (Cache, error) {
+	cacheHome := getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		home, err := userHomeDir()
+		if err != nil {
+			return Cache{}, fmt.Errorf("Couldn't find thumbnail cache: %w", err)
+		}
+		cacheHome = filepath.Join(home, ".cache")
+	}
+	return Cache{filepath.Join(cacheHome, "thumbnails")}, nil
+}
+
+// Dir returns the name of the directory that contains thumbnails of this size.
+func (size Size) Dir() string {
+	switch {
+	case size <= Normal:
+		return "normal"
+	case size <= Large:
+		return "large"
+	case size <= XLarge:
+		return "x-large"
+	default:
+		return "xx-large"
+	}
+}
+
+// Path returns the path where the thumbnail of a file would be stored.
+func (c Cache) Path(filename string, size Size) (string, error) {
+	uri, err := fileURI(filename)
+	if err != nil {
+		return "", err
+	}
+	sum := md5.Sum([]byte(uri))
+	return filepath.Join(c.Dir, size.Dir(), hex.EncodeToString(sum[:])+".png"), nil
+}
+
+// Load loads the thumbnail of a file. ErrNotCached is returned if there is
+// no thumbnail, or if the file has been modified since the thumbnail was
+// created.
+func (c Cache) Load(filename string, size Size) (image.Image, error) {
+	uri, err := fileURI(filename)
+	if err != nil {
+		return nil, err
+	}
+	mtime, err := modTime(filename)
+	if err != nil {
+		return nil, err
+	}
+	path, err := c.Path(filename, size)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ErrNotCached
+	}
+	text := readTextChunks(data)
+	if text[uriKey] != uri || text[mtimeKey] != mtime {
+		return nil, ErrNotCached
+	}
+	m, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrNotCached
+	}
+	return m, nil
+}
+
+// Save scales an image down to the thumbnail size and stores it as the
+// thumbnail of a file.
+func (c Cache) Save(filename string, size Size, m image.Image) error {
+	uri, err := fileURI(filename)
+	if err != nil {
+		return err
+	}
+	mtime, err := modTime(filename)
+	if err != nil {
+		return err
+	}
+	path, err := c.Path(filename, size)
+	if err != nil {
+		return err
+	}
+	thumb := imaging.Fit(m, int(size), int(size), imaging.Lanczos)
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, thumb); err != nil {
+		return err
+	}
+	data := insertTextChunks(buf.Bytes(), [][2]string{
+		{uriKey, uri},
+		{mtimeKey, mtime},
+	})
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	// NOTE The standard requires writing to a temporary file and renaming it,
+	//      so that other programs never read a partial thumbnail.
+	tmp, err := os.CreateTemp(dir, "termage-*.png")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// FileURI converts a filename to the URI used to identify thumbnails.
+func fileURI(filename string) (string, error) {
+	abs, err := absPath(filename)
+	if err != nil {
+		return "", err
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}
+	return u.String(), nil
+}
+
+// ModTime gets the modification time of a file as a string of seconds since
+// the epoch.
+func modTime(filename string) (string, error) {
+	stats, err := osStat(filename)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(stats.ModTime().Unix(), 10), nil
+}
+
+// PNG files start with an 8-byte signature, followed by the IHDR chunk, which
+// has a 13-byte body.
+const (
+	pngSignatureLength = 8
+	ihdrEnd            = pngSignatureLength + 4 + 4 + 13 + 4
+)
+
+// InsertTextChunks adds tEXt chunks after the IHDR chunk of an encoded PNG.
+func insertTextChunks(data []byte, text [][2]string) []byte {
+	out := make([]byte, 0, len(data)+64*len(text))
+	out = append(out, data[:ihdrEnd]...)
+	for _, kv := range text {
+		body := append([]byte(kv[0]), 0)
+		body = append(body, kv[1]...)
+		out = appendChunk(out, "tEXt", body)
+	}
+	return append(out, data[ihdrEnd:]...)
+}
+
+// AppendChunk appends a PNG chunk with a length and CRC.
+func appendChunk(out []byte, chunkType string, body []byte) []byte {
+	out = binary.BigEndian.AppendUint32(out, uint32(len(body)))
+	start := len(out)
+	out = append(out, chunkType...)
+	out = append(out, body...)
+	out = binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out[start:]))
+	return out
+}
+
+// ReadTextChunks collects the keys and values of all tEXt chunks in an
+// encoded PNG.
+func readTextChunks(data []byte) map[string]string {
+	text := make(map[string]string)
+	for i := pngSignatureLength; i+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		chunkType := string(data[i+4 : i+8])
+		bodyStart := i + 8
+		bodyEnd := bodyStart + length
+		if length < 0 || bodyEnd+4 > len(data) {
+			break
+		}
+		if chunkType == "IDAT" || chunkType == "IEND" {
+			break
+		}
+		if chunkType == "tEXt" {
+			body := data[bodyStart:bodyEnd]
+			if sep := bytes.IndexByte(body, 0); sep >= 0 {
+				text[string(body[:sep])] = string(body[sep+1:])
+			}
+		}
+		i = bodyEnd + 4
+	}
+	return text
+}
@@ -0,0 +1,136 @@
+package thumbnail
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDefaultCache checks that the cache would be located in XDG_CACHE_HOME.
+func TestDefaultCache(t *testing.T) {
+	getenv = func(key string) string {
+		if key == "XDG_CACHE_HOME" {
+			return filepath.FromSlash("/tmp/cache")
+		}
+		return ""
+	}
+	defer func() {
+		getenv = os.Getenv
+	}()
+
+	c, err := DefaultCache()
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if want := filepath.FromSlash("/tmp/cache/thumbnails"); c.Dir != want {
+		t.Errorf(`Dir = %q, want %q`, c.Dir, want)
+	}
+}
+
+// TestSizeDir checks that sizes would be stored in the directories defined
+// by the standard.
+func TestSizeDir(t *testing.T) {
+	sizeToDir := map[Size]string{
+		Normal:  "normal",
+		Large:   "large",
+		XLarge:  "x-large",
+		XXLarge: "xx-large",
+	}
+	for size, want := range sizeToDir {
+		if actual := size.Dir(); actual != want {
+			t.Errorf(`Size(%d).Dir() = %q, want %q`, size, actual, want)
+		}
+	}
+}
+
+// TestPath checks that a thumbnail would be named after the MD5 hash of the
+// file's URI.
+func TestPath(t *testing.T) {
+	absPath = func(path string) (string, error) {
+		return "/home/jens/photos/me.png", nil
+	}
+	defer func() {
+		absPath = filepath.Abs
+	}()
+
+	c := Cache{"thumbs"}
+	path, err := c.Path("me.png", Normal)
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	// NOTE Example from the thumbnail managing standard.
+	want := filepath.Join("thumbs", "normal", "c6ee772d9e49320e97ec29a7eb5b1697.png")
+	if path != want {
+		t.Errorf(`path = %q, want %q`, path, want)
+	}
+}
+
+// TestSaveAndLoad checks that a saved thumbnail can be loaded, and is scaled
+// down to the thumbnail size.
+func TestSaveAndLoad(t *testing.T) {
+	c := Cache{t.TempDir()}
+	filename := filepath.Join(t.TempDir(), "big.png")
+	if err := os.WriteFile(filename, nil, 0600); err != nil {
+		panic(err)
+	}
+
+	if _, err := c.Load(filename, Normal); err != ErrNotCached {
+		t.Fatalf(`err = %v, want %v`, err, ErrNotCached)
+	}
+
+	if err := c.Save(filename, Normal, image.NewRGBA(image.Rect(0, 0, 512, 256))); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	m, err := c.Load(filename, Normal)
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if actual, want := m.Bounds().Size(), (image.Point{128, 64}); actual != want {
+		t.Errorf(`size = %v, want %v`, actual, want)
+	}
+}
+
+// TestStaleThumbnail checks that a thumbnail would not be loaded if the file
+// has been modified after the thumbnail was created.
+func TestStaleThumbnail(t *testing.T) {
+	c := Cache{t.TempDir()}
+	filename := filepath.Join(t.TempDir(), "big.png")
+	if err := os.WriteFile(filename, nil, 0600); err != nil {
+		panic(err)
+	}
+	if err := c.Save(filename, Normal, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filename, later, later); err != nil {
+		panic(err)
+	}
+
+	if _, err := c.Load(filename, Normal); err != ErrNotCached {
+		t.Errorf(`err = %v, want %v`, err, ErrNotCached)
+	}
+}
+
+// TestTextChunks checks that text chunks can be written to and read from an
+// encoded PNG.
+func TestTextChunks(t *testing.T) {
+	signature := "\x89PNG\r\n\x1a\n"
+	ihdr := appendChunk(nil, "IHDR", make([]byte, 13))
+	iend := appendChunk(nil, "IEND", nil)
+	data := []byte(signature + string(ihdr) + string(iend))
+
+	data = insertTextChunks(data, [][2]string{{"key", "value"}})
+
+	if !strings.HasSuffix(string(data), string(iend)) {
+		t.Errorf(`IEND chunk is not last`)
+	}
+	text := readTextChunks(data)
+	if actual, want := text["key"], "value"; actual != want {
+		t.Errorf(`text["key"] = %q, want %q`, actual, want)
+	}
+}
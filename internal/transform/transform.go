@@ -0,0 +1,90 @@
+// Package transform rotates and flips images for the view.
+package transform
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+)
+
+// Transform rotates and flips an image. The image is mirrored first, and then
+// rotated. The zero value doesn't change images.
+type Transform struct {
+	// Rotation is the clockwise rotation in degrees: 0, 90, 180 or 270.
+	Rotation int
+	// Mirror flips the image horizontally before it is rotated.
+	Mirror bool
+}
+
+// ParseRotation parses a rotation in degrees, which must be a multiple of 90.
+// Counter-clockwise rotations are negative.
+func ParseRotation(s string) (int, error) {
+	degrees, err := strconv.Atoi(s)
+	if err != nil || degrees%90 != 0 {
+		return 0, fmt.Errorf("Invalid rotation %q, want 0, 90, 180 or 270", s)
+	}
+	return normalize(degrees), nil
+}
+
+// Normalize limits a rotation to 0, 90, 180 or 270 degrees.
+func normalize(degrees int) int {
+	return ((degrees % 360) + 360) % 360
+}
+
+// Rotate rotates the view clockwise by a number of degrees. Counter-clockwise
+// rotations are negative.
+func (t Transform) Rotate(degrees int) Transform {
+	t.Rotation = normalize(t.Rotation + degrees)
+	return t
+}
+
+// FlipHorizontal flips the view, as it is currently rotated, horizontally.
+func (t Transform) FlipHorizontal() Transform {
+	// NOTE Mirroring after rotating is the same as mirroring before rotating
+	// the other way
+	return Transform{normalize(-t.Rotation), !t.Mirror}
+}
+
+// FlipVertical flips the view, as it is currently rotated, vertically.
+func (t Transform) FlipVertical() Transform {
+	// NOTE A vertical flip is a horizontal flip rotated by 180 degrees
+	return t.FlipHorizontal().Rotate(180)
+}
+
+// IsIdentity checks if the transform doesn't change images.
+func (t Transform) IsIdentity() bool {
+	return t.Rotation == 0 && !t.Mirror
+}
+
+// Apply transforms an image. If the transform doesn't change images, the
+// image is returned unchanged.
+func (t Transform) Apply(m image.Image) image.Image {
+	if t.IsIdentity() {
+		return m
+	}
+	if t.Mirror {
+		m = imaging.FlipH(m)
+	}
+	// NOTE imaging rotates counter-clockwise
+	switch t.Rotation {
+	case 90:
+		m = imaging.Rotate270(m)
+	case 180:
+		m = imaging.Rotate180(m)
+	case 270:
+		m = imaging.Rotate90(m)
+	}
+	return m
+}
+
+// Bounds gets the bounds of an image after it is transformed, which have the
+// width and height swapped if it is rotated by 90 or 270 degrees.
+func (t Transform) Bounds(bounds image.Rectangle) image.Rectangle {
+	size := bounds.Size()
+	if t.Rotation == 90 || t.Rotation == 270 {
+		size.X, size.Y = size.Y, size.X
+	}
+	return image.Rectangle{Max: size}
+}
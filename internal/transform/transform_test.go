@@ -0,0 +1,100 @@
+package transform
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// Corner creates a 3x2 image with a white pixel in the top-left corner.
+func corner() image.Image {
+	m := image.NewGray(image.Rect(0, 0, 3, 2))
+	m.SetGray(0, 0, color.Gray{0xFF})
+	return m
+}
+
+// WhitePixel finds the white pixel of a transformed corner image.
+func whitePixel(m image.Image) image.Point {
+	bounds := m.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if r, _, _, _ := m.At(x, y).RGBA(); r == 0xFFFF {
+				return image.Point{x, y}
+			}
+		}
+	}
+	return image.Point{-1, -1}
+}
+
+// TestApply checks that images would be mirrored and then rotated clockwise.
+func TestApply(t *testing.T) {
+	for _, tt := range []struct {
+		transform Transform
+		size      image.Point
+		white     image.Point
+	}{
+		{Transform{}, image.Point{3, 2}, image.Point{0, 0}},
+		{Transform{90, false}, image.Point{2, 3}, image.Point{1, 0}},
+		{Transform{180, false}, image.Point{3, 2}, image.Point{2, 1}},
+		{Transform{270, false}, image.Point{2, 3}, image.Point{0, 2}},
+		{Transform{0, true}, image.Point{3, 2}, image.Point{2, 0}},
+		{Transform{90, true}, image.Point{2, 3}, image.Point{1, 2}},
+	} {
+		m := tt.transform.Apply(corner())
+		if size := m.Bounds().Size(); size != tt.size {
+			t.Errorf(`%+v: size = %v, want %v`, tt.transform, size, tt.size)
+		}
+		if size := tt.transform.Bounds(corner().Bounds()).Size(); size != tt.size {
+			t.Errorf(`%+v: Bounds size = %v, want %v`, tt.transform, size, tt.size)
+		}
+		if white := whitePixel(m); white != tt.white {
+			t.Errorf(`%+v: white pixel = %v, want %v`, tt.transform, white, tt.white)
+		}
+	}
+}
+
+// TestFlipRotated checks that flips would flip the view as it is currently
+// rotated.
+func TestFlipRotated(t *testing.T) {
+	rotated := Transform{}.Rotate(90)
+	// NOTE The white pixel is in the top-right corner of the rotated view
+	for _, tt := range []struct {
+		name      string
+		transform Transform
+		white     image.Point
+	}{
+		{"horizontal", rotated.FlipHorizontal(), image.Point{0, 0}},
+		{"vertical", rotated.FlipVertical(), image.Point{1, 2}},
+	} {
+		if white := whitePixel(tt.transform.Apply(corner())); white != tt.white {
+			t.Errorf(`%s: white pixel = %v, want %v`, tt.name, white, tt.white)
+		}
+	}
+}
+
+// TestFlipTwice checks that flipping twice would undo the flip.
+func TestFlipTwice(t *testing.T) {
+	start := Transform{}.Rotate(270)
+	if actual := start.FlipHorizontal().FlipHorizontal(); actual != start {
+		t.Errorf(`horizontal flips = %+v, want %+v`, actual, start)
+	}
+	if actual := start.FlipVertical().FlipVertical(); actual != start {
+		t.Errorf(`vertical flips = %+v, want %+v`, actual, start)
+	}
+}
+
+// TestParseRotation checks that rotations would be normalized, and that
+// rotations that aren't multiples of 90 degrees would be rejected.
+func TestParseRotation(t *testing.T) {
+	for s, want := range map[string]int{"0": 0, "90": 90, "-90": 270, "450": 90} {
+		actual, err := ParseRotation(s)
+		if err != nil || actual != want {
+			t.Errorf(`ParseRotation(%q) = %d, %v, want %d`, s, actual, err, want)
+		}
+	}
+	for _, s := range []string{"45", "right", ""} {
+		if _, err := ParseRotation(s); err == nil {
+			t.Errorf(`ParseRotation(%q) err = nil`, s)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/spenserblack/termage/internal/exif"
 	"github.com/spenserblack/termage/pkg/gif"
 )
 
@@ -35,6 +36,67 @@ func TestOpenImage(t *testing.T) {
 	}
 }
 
+// TestLoadImageMetadata checks that the format and file size would be
+// returned.
+func TestLoadImageMetadata(t *testing.T) {
+	_, _, meta, err := LoadImageMetadata(getResource("pixel.jpg"))
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if meta.Format != "jpeg" || meta.FileSize != 518 {
+		t.Errorf(`meta = %+v, want jpeg format and 518 bytes`, meta)
+	}
+}
+
+// TestLoadMetadata checks that the title, the size and the metadata of a file
+// would be loaded without decoding the image.
+func TestLoadMetadata(t *testing.T) {
+	mockDecode(testError)
+	defer resetDecode()
+	title, size, meta, err := LoadMetadata(getResource("pixel.jpg"))
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if want := "pixel.jpg [jpeg]"; title != want {
+		t.Errorf(`title = %q, want %q`, title, want)
+	}
+	if want := (image.Point{1, 1}); size != want {
+		t.Errorf(`size = %v, want %v`, size, want)
+	}
+	if meta.Format != "jpeg" || meta.FileSize != 518 {
+		t.Errorf(`meta = %+v, want jpeg format and 518 bytes`, meta)
+	}
+}
+
+// TestLoadOriented checks that an image would be transformed by its EXIF
+// orientation.
+func TestLoadOriented(t *testing.T) {
+	mockOpen(nil)
+	defer resetOpen()
+	decode = func(io.Reader) (image.Image, string, error) {
+		return image.NewGray(image.Rect(0, 0, 2, 1)), "jpeg", nil
+	}
+	defer resetDecode()
+	decodeEXIF = func(io.Reader) (*exif.EXIF, error) {
+		return &exif.EXIF{Orientation: exif.Rotate90}, nil
+	}
+	defer func() {
+		decodeEXIF = exif.Decode
+	}()
+
+	m, _, meta, err := LoadImageMetadata("")
+
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if size, want := m.Bounds().Size(), (image.Point{1, 2}); size != want {
+		t.Errorf(`size = %v, want %v`, size, want)
+	}
+	if meta.EXIF == nil {
+		t.Errorf(`meta.EXIF = nil`)
+	}
+}
+
 // TestLoadNotAnimated checks that an unanimated GIF will be treated as such.
 func TestLoadNotAnimated(t *testing.T) {
 	m, _, err := LoadImage(getResource("not-animated-pixel.gif"))
@@ -3,9 +3,11 @@ package utils
 import (
 	"fmt"
 	"image"
+	"image/color"
 	"os"
 	"path/filepath"
 
+	"github.com/spenserblack/termage/internal/exif"
 	"github.com/spenserblack/termage/pkg/gif"
 )
 
@@ -14,27 +16,48 @@ var ErrNotAnimated = gif.ErrNotAnimated
 
 // Variables for that can be changed for testing.
 var (
-	open   = os.Open
-	decode = image.Decode
+	open       = os.Open
+	decode     = image.Decode
+	decodeEXIF = exif.Decode
 )
 
+// Metadata describes an image file.
+type Metadata struct {
+	// Format is the name of the image format, like "png".
+	Format string
+	// FileSize is the size of the file in bytes.
+	FileSize int64
+	// EXIF is the EXIF metadata of the image, or nil if it has none.
+	EXIF *exif.EXIF
+}
+
 // LoadImage returns the image data and the title of the image.
 func LoadImage(filename string) (m image.Image, title string, err error) {
+	m, title, _, err = LoadImageMetadata(filename)
+	return
+}
+
+// LoadImageMetadata is like LoadImage, but also returns metadata of the file.
+// Images with an EXIF orientation are transformed to be upright.
+func LoadImageMetadata(filename string) (m image.Image, title string, meta Metadata, err error) {
 	reader, err := open(filename)
 	if err != nil {
 		err = fmt.Errorf("Couldn't open %q: %w", filename, err)
 		return
 	}
 	defer reader.Close()
+	if stats, statErr := reader.Stat(); statErr == nil {
+		meta.FileSize = stats.Size()
+	}
 
-	m, format, err := decode(reader)
+	m, meta.Format, err = decode(reader)
 	if err != nil {
 		title = filepath.Base(filename)
 		err = fmt.Errorf("Couldn't decode %q: %w", filename, err)
 		return
 	}
-	title = formatTitle(filename, format)
-	if format == "gif" {
+	title = formatTitle(filename, meta.Format)
+	if meta.Format == "gif" {
 		reader.Seek(0, 0)
 		var helper gif.Helper
 		helper, err = gif.HelperFromReader(reader)
@@ -44,9 +67,110 @@ func LoadImage(filename string) (m image.Image, title string, err error) {
 		m = &helper
 		return
 	}
+	reader.Seek(0, 0)
+	if x, exifErr := decodeEXIF(reader); exifErr == nil {
+		meta.EXIF = x
+		m = x.Orientation.Apply(m)
+	}
 	return
 }
 
+// LoadMetadata loads the title, the size and the metadata of an image file
+// without decoding the image, which is much faster for large images.
+func LoadMetadata(filename string) (title string, size image.Point, meta Metadata, err error) {
+	reader, err := open(filename)
+	if err != nil {
+		err = fmt.Errorf("Couldn't open %q: %w", filename, err)
+		return
+	}
+	defer reader.Close()
+	if stats, statErr := reader.Stat(); statErr == nil {
+		meta.FileSize = stats.Size()
+	}
+
+	config, format, err := image.DecodeConfig(reader)
+	if err != nil {
+		title = filepath.Base(filename)
+		err = fmt.Errorf("Couldn't decode %q: %w", filename, err)
+		return
+	}
+	meta.Format = format
+	title = formatTitle(filename, meta.Format)
+	size = image.Point{config.Width, config.Height}
+	reader.Seek(0, 0)
+	if x, exifErr := decodeEXIF(reader); exifErr == nil {
+		meta.EXIF = x
+		if x.Orientation.Swaps() {
+			size = image.Point{size.Y, size.X}
+		}
+	}
+	return
+}
+
+// ColorModelName gets a readable name of a color model, like "RGBA" or
+// "Paletted (256 colors)".
+func ColorModelName(model color.Model) string {
+	if palette, ok := model.(color.Palette); ok {
+		return fmt.Sprintf("Paletted (%d colors)", len(palette))
+	}
+	for _, named := range colorModelNames {
+		if named.model == model {
+			return named.name
+		}
+	}
+	return "Unknown"
+}
+
+// BitDepth gets the number of bits of each channel of a color model. For
+// palettes, it is the number of bits of each palette index.
+func BitDepth(model color.Model) int {
+	if palette, ok := model.(color.Palette); ok {
+		depth := 1
+		for 1<<depth < len(palette) {
+			depth++
+		}
+		return depth
+	}
+	switch model {
+	case color.RGBA64Model, color.NRGBA64Model, color.Alpha16Model, color.Gray16Model:
+		return 16
+	}
+	return 8
+}
+
+// FormatFileSize formats a number of bytes with binary units, like "1.2 MiB".
+func FormatFileSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	size := float64(bytes) / unit
+	prefixes := "KMGTPE"
+	i := 0
+	for ; size >= unit && i < len(prefixes)-1; i++ {
+		size /= unit
+	}
+	return fmt.Sprintf("%.1f %ciB", size, prefixes[i])
+}
+
+// ColorModelNames are the names of the standard library's color models.
+var colorModelNames = []struct {
+	model color.Model
+	name  string
+}{
+	{color.RGBAModel, "RGBA"},
+	{color.RGBA64Model, "RGBA64"},
+	{color.NRGBAModel, "NRGBA"},
+	{color.NRGBA64Model, "NRGBA64"},
+	{color.AlphaModel, "Alpha"},
+	{color.Alpha16Model, "Alpha16"},
+	{color.GrayModel, "Gray"},
+	{color.Gray16Model, "Gray16"},
+	{color.YCbCrModel, "YCbCr"},
+	{color.NYCbCrAModel, "NYCbCrA"},
+	{color.CMYKModel, "CMYK"},
+}
+
 // FormatTitle creates a proper title for an image.
 func formatTitle(filename, imageFormat string) string {
 	return fmt.Sprintf("%v [%v]", filepath.Base(filename), imageFormat)
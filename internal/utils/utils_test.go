@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"image/color"
+	"image/color/palette"
 	"path/filepath"
 	"testing"
 )
@@ -16,3 +18,56 @@ func TestFormatTitle(t *testing.T) {
 		t.Fatalf(`title = %q, want %q`, title, want)
 	}
 }
+
+// TestColorModelName checks that color models would be named, including the
+// size of palettes.
+func TestColorModelName(t *testing.T) {
+	for _, tt := range []struct {
+		model color.Model
+		want  string
+	}{
+		{color.NRGBAModel, "NRGBA"},
+		{color.YCbCrModel, "YCbCr"},
+		{color.Palette(palette.Plan9), "Paletted (256 colors)"},
+	} {
+		if actual := ColorModelName(tt.model); actual != tt.want {
+			t.Errorf(`ColorModelName = %q, want %q`, actual, tt.want)
+		}
+	}
+}
+
+// TestFormatFileSize checks that file sizes would be formatted with binary
+// units.
+func TestFormatFileSize(t *testing.T) {
+	for _, tt := range []struct {
+		bytes int64
+		want  string
+	}{
+		{518, "518 B"},
+		{1536, "1.5 KiB"},
+		{5 << 30, "5.0 GiB"},
+	} {
+		if actual := FormatFileSize(tt.bytes); actual != tt.want {
+			t.Errorf(`FormatFileSize(%d) = %q, want %q`, tt.bytes, actual, tt.want)
+		}
+	}
+}
+
+// TestBitDepth checks that the bit depth would be found for standard models
+// and palettes.
+func TestBitDepth(t *testing.T) {
+	for _, tt := range []struct {
+		model color.Model
+		want  int
+	}{
+		{color.RGBAModel, 8},
+		{color.Gray16Model, 16},
+		{color.Palette(palette.Plan9), 8},
+		{color.Palette{color.Black, color.White}, 1},
+		{color.Palette(palette.Plan9[:5]), 3},
+	} {
+		if actual := BitDepth(tt.model); actual != tt.want {
+			t.Errorf(`BitDepth(%s) = %d, want %d`, ColorModelName(tt.model), actual, tt.want)
+		}
+	}
+}
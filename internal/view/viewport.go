@@ -0,0 +1,110 @@
+package view
+
+import (
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+
+	"github.com/spenserblack/termage/internal/conversion"
+	"github.com/spenserblack/termage/pkg/render"
+)
+
+// Viewport is the part of a zoomed image that has been rendered. Only the
+// visible part of an image and a margin around it are rendered, so that
+// zooming into large images costs about as much as the size of the image area.
+type Viewport struct {
+	// Rect is the part of the zoomed image that is rendered, in cells from
+	// the top-left corner of the zoomed image.
+	Rect     image.Rectangle
+	RGBRunes conversion.RGBRunes
+}
+
+// VisibleRect gets the part of a zoomed image of a size that is visible in
+// the image area when the image is drawn at an offset from the center.
+func VisibleRect(offset, size, area image.Point) image.Rectangle {
+	// NOTE The top-left corner that draw.ImageAt draws the image at to
+	//      center it in the image area
+	topLeft := area.Div(2).Sub(size.Div(2)).Add(offset)
+	return image.Rectangle{Max: area}.Sub(topLeft).Intersect(image.Rectangle{Max: size})
+}
+
+// RenderRect gets the part of a zoomed image that should be rendered: the
+// visible part, and a margin of a quarter of the image area around it so that
+// short scrolls don't need to render the image again.
+func RenderRect(offset, size, area image.Point) image.Rectangle {
+	visible := VisibleRect(offset, size, area)
+	margin := area.Div(4)
+	return image.Rectangle{visible.Min.Sub(margin), visible.Max.Add(margin)}.Intersect(image.Rectangle{Max: size})
+}
+
+// Covers checks if the visible part of a zoomed image has been rendered.
+func (v Viewport) Covers(offset, size, area image.Point) bool {
+	return VisibleRect(offset, size, area).In(v.Rect)
+}
+
+// DrawOffset gets the offset to draw the rendered part at, so that it lines
+// up with the whole zoomed image drawn at an offset.
+func (v Viewport) DrawOffset(offset, size image.Point) image.Point {
+	rendered := image.Point{v.RGBRunes.Width(), v.RGBRunes.Height()}
+	return offset.Sub(size.Div(2)).Add(v.Rect.Min).Add(rendered.Div(2))
+}
+
+// RenderViewport zooms and converts the part of an image that is rendered in
+// a view. The image must already be transformed.
+func RenderViewport(m image.Image, v View, converter conversion.Converter) Viewport {
+	return Viewport{v.Rect, converter.FromImage(v.Zoom.cropZoom(m, v.Rect, v.Resampling))}
+}
+
+// CropZoom zooms the part of an image inside a rectangle of cells of the
+// zoomed image, without zooming the rest of the image.
+func (percentage Zoom) cropZoom(m image.Image, rect image.Rectangle, resampling render.Filter) image.Image {
+	bounds := m.Bounds()
+	size := percentage.Size(bounds)
+	if rect == (image.Rectangle{Max: size}) {
+		return percentage.TransImage(m, resampling)
+	}
+	if rect.Empty() {
+		return image.NewNRGBA(image.Rectangle{})
+	}
+	scaleX := float64(size.X) / float64(bounds.Dx())
+	scaleY := float64(size.Y) / float64(bounds.Dy())
+	// NOTE The image is cropped to whole pixels, which are zoomed and then
+	//      cropped to the rectangle, so that the cells line up with the cells
+	//      of the whole zoomed image.
+	pixels := image.Rect(
+		int(math.Floor(float64(rect.Min.X)/scaleX)),
+		int(math.Floor(float64(rect.Min.Y)/scaleY)),
+		int(math.Ceil(float64(rect.Max.X)/scaleX)),
+		int(math.Ceil(float64(rect.Max.Y)/scaleY)),
+	).Intersect(image.Rectangle{Max: bounds.Size()})
+	cells := image.Rect(
+		int(math.Round(float64(pixels.Min.X)*scaleX)),
+		int(math.Round(float64(pixels.Min.Y)*scaleY)),
+		int(math.Round(float64(pixels.Max.X)*scaleX)),
+		int(math.Round(float64(pixels.Max.Y)*scaleY)),
+	)
+	cropped := imaging.Crop(m, pixels.Add(bounds.Min))
+	zoomed := imaging.Resize(cropped, cells.Dx(), cells.Dy(), resampling.Resample())
+	return imaging.Crop(zoomed, rect.Sub(cells.Min))
+}
+
+// ClampOffset limits the offset of a zoomed image of a size from the center of
+// the image area so that the image never scrolls further than its edges.
+// Images that fit the area are centered.
+func ClampOffset(offset, size, area image.Point) (x, y int) {
+	clampAxis := func(offset, size, area int) int {
+		if size <= area {
+			return 0
+		}
+		min, max := (area-size)/2, (size-area)/2
+		if offset < min {
+			return min
+		}
+		if offset > max {
+			return max
+		}
+		return offset
+	}
+	return clampAxis(offset.X, size.X, area.X), clampAxis(offset.Y, size.Y, area.Y)
+}
@@ -0,0 +1,122 @@
+package view
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/spenserblack/termage/internal/conversion"
+	"github.com/spenserblack/termage/pkg/render"
+)
+
+// TestVisibleRect checks that only the part of a zoomed image inside the
+// image area would be visible.
+func TestVisibleRect(t *testing.T) {
+	area := image.Point{100, 50}
+	for _, tt := range []struct {
+		offset, size image.Point
+		want         image.Rectangle
+	}{
+		{image.Point{}, image.Point{40, 20}, image.Rect(0, 0, 40, 20)},
+		{image.Point{}, image.Point{1000, 500}, image.Rect(450, 225, 550, 275)},
+		{image.Point{450, -225}, image.Point{1000, 500}, image.Rect(0, 450, 100, 500)},
+	} {
+		if actual := VisibleRect(tt.offset, tt.size, area); actual != tt.want {
+			t.Errorf(`VisibleRect(%v, %v) = %v, want %v`, tt.offset, tt.size, actual, tt.want)
+		}
+	}
+}
+
+// TestRenderRect checks that a margin around the visible part would be
+// rendered, without going past the edges of the zoomed image.
+func TestRenderRect(t *testing.T) {
+	area := image.Point{100, 50}
+	size := image.Point{1000, 500}
+	if actual, want := RenderRect(image.Point{}, size, area), image.Rect(425, 213, 575, 287); actual != want {
+		t.Errorf(`centered = %v, want %v`, actual, want)
+	}
+	if actual, want := RenderRect(image.Point{450, 225}, size, area), image.Rect(0, 0, 125, 62); actual != want {
+		t.Errorf(`top-left corner = %v, want %v`, actual, want)
+	}
+}
+
+// TestCovers checks that scrolling within the margin wouldn't need the image
+// to be rendered again.
+func TestCovers(t *testing.T) {
+	area := image.Point{100, 50}
+	size := image.Point{1000, 500}
+	v := Viewport{Rect: RenderRect(image.Point{}, size, area)}
+	if !v.Covers(image.Point{20, 10}, size, area) {
+		t.Errorf(`scrolling within the margin isn't covered`)
+	}
+	if v.Covers(image.Point{30, 0}, size, area) {
+		t.Errorf(`scrolling past the margin is covered`)
+	}
+}
+
+// TestCropZoom checks that zooming part of an image would give the same
+// cells as zooming the whole image and cropping it.
+func TestCropZoom(t *testing.T) {
+	m := image.NewGray(image.Rect(0, 0, 40, 30))
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 40; x++ {
+			m.SetGray(x, y, color.Gray{uint8(x*6 + y)})
+		}
+	}
+	var zoom Zoom = 500
+	full := zoom.TransImage(m, render.Linear)
+	rect := image.Rect(110, 40, 190, 70)
+	part := zoom.cropZoom(m, rect, render.Linear)
+
+	if size := part.Bounds().Size(); size != rect.Size() {
+		t.Fatalf(`size = %v, want %v`, size, rect.Size())
+	}
+	// NOTE Cells away from the edges of the crop aren't affected by the
+	//      pixels outside of it
+	for _, p := range []image.Point{{10, 10}, {40, 15}, {70, 20}} {
+		want := color.GrayModel.Convert(full.At(rect.Min.X+p.X, rect.Min.Y+p.Y)).(color.Gray)
+		actual := color.GrayModel.Convert(part.At(p.X, p.Y)).(color.Gray)
+		if diff := int(actual.Y) - int(want.Y); diff < -1 || diff > 1 {
+			t.Errorf(`cell %v = %v, want %v`, p, actual, want)
+		}
+	}
+}
+
+// TestDrawOffset checks that the rendered part would be drawn where it is in
+// the whole zoomed image.
+func TestDrawOffset(t *testing.T) {
+	area := image.Point{100, 50}
+	m := image.NewGray(image.Rect(0, 0, 400, 500))
+	var zoom Zoom = 100
+	size := zoom.Size(m.Bounds())
+	offset := image.Point{7, -3}
+	rect := RenderRect(offset, size, area)
+	v := RenderViewport(m, View{Zoom: zoom, Rect: rect}, conversion.Converter{})
+	// NOTE Top-left corners that draw.ImageAt draws centered images at
+	topLeft := func(offset image.Point, width, height int) image.Point {
+		return area.Div(2).Sub(image.Point{width, height}.Div(2)).Add(offset)
+	}
+	whole := topLeft(offset, size.X, size.Y)
+	part := topLeft(v.DrawOffset(offset, size), v.RGBRunes.Width(), v.RGBRunes.Height())
+	if want := whole.Add(rect.Min); part != want {
+		t.Errorf(`top-left corner = %v, want %v`, part, want)
+	}
+}
+
+// TestClampOffset checks that images can't be scrolled past their edges, and
+// that images that fit are centered.
+func TestClampOffset(t *testing.T) {
+	area := image.Point{10, 10}
+	for _, tt := range []struct {
+		offset, size image.Point
+		wantX, wantY int
+	}{
+		{image.Point{100, -100}, image.Point{20, 30}, 5, -10},
+		{image.Point{3, 3}, image.Point{20, 5}, 3, 0},
+	} {
+		x, y := ClampOffset(tt.offset, tt.size, area)
+		if x != tt.wantX || y != tt.wantY {
+			t.Errorf(`ClampOffset(%v, %v) = %d, %d, want %d, %d`, tt.offset, tt.size, x, y, tt.wantX, tt.wantY)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Package view zooms, scrolls and renders the visible part of images for the
+// image area of a screen.
+package view
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+
+	"github.com/spenserblack/termage/internal/conversion"
+	"github.com/spenserblack/termage/internal/transform"
+	"github.com/spenserblack/termage/pkg/render"
+)
+
+// Zoom is a zoom level as a percentage. At 100%, each pixel of an image is
+// drawn one cell tall.
+type Zoom float64
+
+// Limits of the zoom, and the factor that each zoom step multiplies the zoom
+// by.
+const (
+	MinZoom  Zoom = 1
+	MaxZoom  Zoom = 1600
+	zoomStep Zoom = 1.25
+)
+
+// ZoomMode decides the zoom of images when they are opened and when the
+// screen is resized.
+type ZoomMode int
+
+const (
+	// ZoomFit fits the whole image in the screen, without enlarging it.
+	ZoomFit ZoomMode = iota
+	// ZoomFitWidth fits the width of the image to the screen.
+	ZoomFitWidth
+	// ZoomFitHeight fits the height of the image to the screen.
+	ZoomFitHeight
+	// ZoomActualSize draws each pixel of the image one cell tall.
+	ZoomActualSize
+	// ZoomFixed uses a fixed zoom percentage.
+	ZoomFixed
+)
+
+// Names of zoom modes, as used in options. Fixed zooms are written as
+// percentages instead.
+var zoomModeNames = map[ZoomMode]string{
+	ZoomFit:        "fit",
+	ZoomFitWidth:   "fit-width",
+	ZoomFitHeight:  "fit-height",
+	ZoomActualSize: "1:1",
+}
+
+// ParseZoom parses a zoom, which is "fit", "fit-width", "fit-height", "1:1"
+// or a percentage like "150" or "150%". The zoom is only returned for
+// percentages.
+func ParseZoom(s string) (ZoomMode, Zoom, error) {
+	for mode, name := range zoomModeNames {
+		if s == name {
+			return mode, 0, nil
+		}
+	}
+	percentage, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || Zoom(percentage) < MinZoom || Zoom(percentage) > MaxZoom {
+		return ZoomFit, 0, fmt.Errorf("Invalid zoom %q, want fit, fit-width, fit-height, 1:1 or a percentage from %v to %v", s, MinZoom, MaxZoom)
+	}
+	return ZoomFixed, Zoom(percentage), nil
+}
+
+// Zoom gets the zoom of an image in an area for the mode. Fixed is the zoom
+// of ZoomFixed.
+func (mode ZoomMode) Zoom(area image.Point, bounds image.Rectangle, fixed Zoom) Zoom {
+	switch mode {
+	case ZoomFitWidth:
+		return fitWidthZoom(area, bounds).clamp()
+	case ZoomFitHeight:
+		return fitHeightZoom(area, bounds).clamp()
+	case ZoomActualSize:
+		return 100
+	case ZoomFixed:
+		return fixed
+	}
+	return FitZoom(area, bounds)
+}
+
+// FitZoom gets the zoom at which an image fits an area. The image is never
+// enlarged.
+func FitZoom(area image.Point, bounds image.Rectangle) Zoom {
+	zoom := fitWidthZoom(area, bounds)
+	if heightZoom := fitHeightZoom(area, bounds); heightZoom < zoom {
+		zoom = heightZoom
+	}
+	if zoom > 100 {
+		zoom = 100
+	}
+	return zoom.clamp()
+}
+
+// FitWidthZoom gets the zoom at which the width of an image fills an area.
+func fitWidthZoom(area image.Point, bounds image.Rectangle) Zoom {
+	// NOTE Adjusts width of "pixels" to match height
+	return Zoom(float64(area.X) * 100 / (float64(bounds.Dx()) * render.PixelHeight))
+}
+
+// FitHeightZoom gets the zoom at which the height of an image fills an area.
+func fitHeightZoom(area image.Point, bounds image.Rectangle) Zoom {
+	return Zoom(float64(area.Y) * 100 / float64(bounds.Dy()))
+}
+
+// Clamp limits a zoom to the minimum and maximum zoom.
+func (percentage Zoom) clamp() Zoom {
+	switch {
+	case percentage < MinZoom:
+		return MinZoom
+	case percentage > MaxZoom:
+		return MaxZoom
+	}
+	return percentage
+}
+
+// Step zooms in or out by the zoom step. Zooming stops at the zoom that fits
+// the image and at 100% if they would be skipped over.
+func (percentage Zoom) Step(fit Zoom, in bool) Zoom {
+	next := percentage / zoomStep
+	if in {
+		next = percentage * zoomStep
+	}
+	for _, stop := range []Zoom{fit, 100} {
+		if in && percentage < stop && stop < next {
+			next = stop
+		}
+		if !in && next < stop && stop < percentage {
+			next = stop
+		}
+	}
+	return next.clamp()
+}
+
+// String formats a zoom like "45%".
+func (percentage Zoom) String() string {
+	return fmt.Sprintf("%.0f%%", float64(percentage))
+}
+
+// TransImage transforms an image by a zoom percentage, resampling it with a
+// filter.
+func (percentage Zoom) TransImage(i image.Image, resampling render.Filter) image.Image {
+	size := percentage.Size(i.Bounds())
+	return imaging.Resize(i, size.X, size.Y, resampling.Resample())
+}
+
+// Size gets the size of an image in cells after it is transformed by a zoom
+// percentage. Images are always at least 1 cell wide and tall.
+func (percentage Zoom) Size(bounds image.Rectangle) image.Point {
+	scale := float64(percentage) / 100
+	// NOTE Adjusts width of "pixels" to match height
+	width := math.Round(float64(bounds.Dx()) * render.PixelHeight * scale)
+	height := math.Round(float64(bounds.Dy()) * scale)
+	return image.Point{int(math.Max(width, 1)), int(math.Max(height, 1))}
+}
+
+// View is how an image is shown: its transform, then its zoom and the filter
+// it is resampled with, and the part of the zoomed image that is rendered.
+type View struct {
+	Transform  transform.Transform
+	Zoom       Zoom
+	Resampling render.Filter
+	// Rect is the part of the zoomed image that is rendered, in cells from
+	// the top-left corner of the zoomed image.
+	Rect image.Rectangle
+}
+
+// Render transforms an image, and then zooms and converts the part of it
+// that is rendered.
+func (v View) Render(i image.Image, converter conversion.Converter) Viewport {
+	return RenderViewport(v.Transform.Apply(i), v, converter)
+}
+
+// AnchorZoom gets the offset of an image after zooming, so that the point of
+// the image under the anchor, a point in the image area, stays under the
+// anchor.
+func AnchorZoom(anchor, offset, area image.Point, bounds image.Rectangle, before, after Zoom) (x, y int) {
+	origin := area.Div(2)
+	beforeSize, afterSize := before.Size(bounds), after.Size(bounds)
+	anchorAxis := func(anchor, origin, offset, before, after int) int {
+		if before == 0 {
+			return 0
+		}
+		// NOTE Position of the anchor within the image, from 0 to 1
+		position := float64(anchor-origin-offset)/float64(before) + 0.5
+		return anchor - origin + int(math.Round((0.5-position)*float64(after)))
+	}
+	return ClampOffset(
+		image.Point{
+			anchorAxis(anchor.X, origin.X, offset.X, beforeSize.X, afterSize.X),
+			anchorAxis(anchor.Y, origin.Y, offset.Y, beforeSize.Y, afterSize.Y),
+		},
+		afterSize,
+		area,
+	)
+}
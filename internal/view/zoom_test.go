@@ -0,0 +1,123 @@
+package view
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// SameZoom checks if zooms are equal, ignoring rounding errors of the pixel
+// height.
+func sameZoom(a, b Zoom) bool {
+	return math.Abs(float64(a-b)) < 0.01
+}
+
+// TestParseZoom checks that zooms would be parsed as zoom modes or
+// percentages.
+func TestParseZoom(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		mode ZoomMode
+		zoom Zoom
+	}{
+		{"fit", ZoomFit, 0},
+		{"fit-width", ZoomFitWidth, 0},
+		{"fit-height", ZoomFitHeight, 0},
+		{"1:1", ZoomActualSize, 0},
+		{"150", ZoomFixed, 150},
+		{"50%", ZoomFixed, 50},
+		{"12.5%", ZoomFixed, 12.5},
+	} {
+		mode, zoom, err := ParseZoom(tt.s)
+		if err != nil {
+			t.Fatalf(`err = %v, want nil`, err)
+		}
+		if mode != tt.mode || zoom != tt.zoom {
+			t.Errorf(`ParseZoom(%q) = %d, %v, want %d, %v`, tt.s, mode, zoom, tt.mode, tt.zoom)
+		}
+	}
+	for _, s := range []string{"", "0", "-5", "big", "2000"} {
+		if _, _, err := ParseZoom(s); err == nil {
+			t.Errorf(`ParseZoom(%q) err = nil`, s)
+		}
+	}
+}
+
+// TestFitZoom checks that the fit zoom would fit the whole image without
+// enlarging it.
+func TestFitZoom(t *testing.T) {
+	area := image.Point{215, 50}
+	for _, tt := range []struct {
+		bounds image.Rectangle
+		want   Zoom
+	}{
+		{image.Rect(0, 0, 100, 100), 50},
+		{image.Rect(0, 0, 200, 50), 50},
+		{image.Rect(0, 0, 10, 10), 100},
+	} {
+		if actual := FitZoom(area, tt.bounds); !sameZoom(actual, tt.want) {
+			t.Errorf(`FitZoom(%v) = %v, want %v`, tt.bounds, actual, tt.want)
+		}
+	}
+}
+
+// TestZoomModeZoom checks that each zoom mode would get its zoom.
+func TestZoomModeZoom(t *testing.T) {
+	area := image.Point{215, 50}
+	bounds := image.Rect(0, 0, 10, 100)
+	for mode, want := range map[ZoomMode]Zoom{
+		ZoomFit:        50,
+		ZoomFitWidth:   1000,
+		ZoomFitHeight:  50,
+		ZoomActualSize: 100,
+		ZoomFixed:      75,
+	} {
+		if actual := mode.Zoom(area, bounds, 75); !sameZoom(actual, want) {
+			t.Errorf(`mode %d: zoom = %v, want %v`, mode, actual, want)
+		}
+	}
+}
+
+// TestStep checks that zoom steps would multiply the zoom, stop at the fit
+// zoom and 100%, and stay within the zoom limits.
+func TestStep(t *testing.T) {
+	for _, tt := range []struct {
+		zoom, fit Zoom
+		in        bool
+		want      Zoom
+	}{
+		{40, 20, true, 50},
+		{50, 20, false, 40},
+		{90, 20, true, 100},
+		{110, 20, false, 100},
+		{18, 20, true, 20},
+		{22, 20, false, 20},
+		{1500, 20, true, MaxZoom},
+		{1.1, 20, false, MinZoom},
+	} {
+		if actual := tt.zoom.Step(tt.fit, tt.in); !sameZoom(actual, tt.want) {
+			t.Errorf(`%v.Step(%v, %t) = %v, want %v`, tt.zoom, tt.fit, tt.in, actual, tt.want)
+		}
+	}
+}
+
+// TestAnchorZoom checks that the point under the anchor would stay in place
+// when zooming.
+func TestAnchorZoom(t *testing.T) {
+	area := image.Point{100, 100}
+	bounds := image.Rect(0, 0, 100, 100)
+	center := image.Point{50, 50}
+
+	x, y := AnchorZoom(center, image.Point{}, area, bounds, 100, 200)
+	if x != 0 || y != 0 {
+		t.Errorf(`zooming at the center = %d, %d, want 0, 0`, x, y)
+	}
+
+	// NOTE At 100%, the image is 215x100 cells, so its top edge is at the top
+	//      of the image area.
+	top := image.Point{50, 0}
+	_, y = AnchorZoom(top, image.Point{}, area, bounds, 100, 200)
+	if want := 50; y != want {
+		t.Errorf(`zooming at the top edge y = %d, want %d`, y, want)
+	}
+}
@@ -31,6 +31,8 @@ type Helper struct {
 	Frames    []Frame
 	loopCount looper
 	index     int
+	// Loops is the loop count of the GIF, before it was played.
+	loops int
 }
 
 // NewHelper constructs a helper for managing animated GIFs.
@@ -73,24 +75,25 @@ func NewHelper(g *gif.GIF) (helper Helper, err error) {
 		})
 	}
 
-	var l looper
-	switch g.LoopCount {
-	case -1:
-		l = noLoop{}
-	case 0:
-		l = infiniteLoop{}
-	default:
-		l = &countLoop{g.LoopCount}
-	}
-
 	helper = Helper{
-		frames,
-		l,
-		0,
+		Frames:    frames,
+		loopCount: newLooper(g.LoopCount),
+		loops:     g.LoopCount,
 	}
 	return
 }
 
+// NewLooper creates a looper for a loop count, as defined by GIF.LoopCount.
+func newLooper(loopCount int) looper {
+	switch loopCount {
+	case -1:
+		return noLoop{}
+	case 0:
+		return infiniteLoop{}
+	}
+	return &countLoop{loopCount}
+}
+
 // HelperFromReader cretes a new GIF helper from a Reader.
 func HelperFromReader(r io.Reader) (helper Helper, err error) {
 	var g *gif.GIF
@@ -107,6 +110,23 @@ func (h Helper) Delay() time.Duration {
 	return time.Duration(h.Frames[h.index].delay) * (time.Second / 100)
 }
 
+// Duration returns the length of one loop of the animation.
+func (h Helper) Duration() time.Duration {
+	var duration time.Duration
+	for _, frame := range h.Frames {
+		duration += time.Duration(frame.delay) * (time.Second / 100)
+	}
+	return duration
+}
+
+// LoopCount returns the number of times that the animation repeats after it
+// is first played, with the same meaning as image/gif's GIF.LoopCount: 0
+// repeats forever, and -1 plays the animation only once. The count decreases
+// as the animation is played.
+func (h Helper) LoopCount() int {
+	return h.loopCount.loops()
+}
+
 // NextFrame moves along to the next frame and generates a new current image.
 //
 // It can return ErrAnimationComplete if the animation is complete and a new image
@@ -123,6 +143,22 @@ func (h *Helper) NextFrame() error {
 	return nil
 }
 
+// Restart returns a copy of the animation at its first frame, with all of its
+// loops left. Playing the copy doesn't change the original, so one decoded
+// GIF can be played any number of times.
+func (h Helper) Restart() *Helper {
+	return &Helper{
+		Frames:    h.Frames,
+		loopCount: newLooper(h.loops),
+		loops:     h.loops,
+	}
+}
+
+// Index returns the index of the current frame.
+func (h Helper) Index() int {
+	return h.index
+}
+
 // CurrentImage is the image representing the current state of the animation.
 func (h *Helper) CurrentImage() image.Image {
 	return h.Frames[h.index]
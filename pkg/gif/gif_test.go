@@ -273,3 +273,81 @@ func getResource(resourceName string) string {
 	dir := thisDirOrPanic()
 	return filepath.Join(dir, "..", "..", "_resources", "tests", "pkg", "gif", resourceName)
 }
+
+// TestLoopCount checks that the loop count would have the same meaning as
+// the GIF's loop count.
+func TestLoopCount(t *testing.T) {
+	for filename, want := range map[string]int{
+		"spinning-2x2.gif":        0,
+		"spinning-2x2-noloop.gif": -1,
+		"spinning-2x2-3loop.gif":  3,
+	} {
+		f, err := os.Open(getResource(filename))
+		if err != nil {
+			panic(err)
+		}
+		gifHelper, err := HelperFromReader(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf(`%s: err = %v, want nil`, filename, err)
+		}
+		if actual := gifHelper.LoopCount(); actual != want {
+			t.Errorf(`%s: LoopCount = %d, want %d`, filename, actual, want)
+		}
+	}
+}
+
+// TestDuration checks that the duration would be the sum of the frames'
+// delays.
+func TestDuration(t *testing.T) {
+	f, err := os.Open(getResource("spinning-2x2.gif"))
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	g, err := gif.DecodeAll(f)
+	if err != nil {
+		panic(err)
+	}
+	gifHelper, err := NewHelper(g)
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	var want time.Duration
+	for _, delay := range g.Delay {
+		want += time.Duration(delay) * 10 * time.Millisecond
+	}
+	if actual := gifHelper.Duration(); actual != want || actual == 0 {
+		t.Errorf(`Duration = %v, want %v`, actual, want)
+	}
+}
+
+// TestRestart checks that a restarted animation would play from its first
+// frame with all of its loops, without changing the original animation.
+func TestRestart(t *testing.T) {
+	f, err := os.Open(getResource("spinning-2x2-3loop.gif"))
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	gifHelper, err := HelperFromReader(f)
+	if err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	for i := 0; i < 9; i++ {
+		gifHelper.NextFrame()
+	}
+
+	restarted := gifHelper.Restart()
+	if actual := restarted.Index(); actual != 0 {
+		t.Errorf(`restarted index = %d, want 0`, actual)
+	}
+	if actual := restarted.LoopCount(); actual != 3 {
+		t.Errorf(`restarted loop count = %d, want 3`, actual)
+	}
+	restarted.NextFrame()
+	if actual := gifHelper.Index(); actual != 1 {
+		t.Errorf(`original index after playing the copy = %d, want 1`, actual)
+	}
+}
@@ -5,6 +5,8 @@ type looper interface {
 	// NextLoop should be called at the end of each loop,
 	// to determine if it should continue.
 	nextLoop() error
+	// Loops is the number of remaining loops, as defined by GIF.LoopCount.
+	loops() int
 }
 
 // InfiniteLoop will never end animation.
@@ -33,3 +35,18 @@ func (l *countLoop) nextLoop() error {
 	}
 	return nil
 }
+
+func (l infiniteLoop) loops() int {
+	return 0
+}
+
+func (l noLoop) loops() int {
+	return -1
+}
+
+func (l *countLoop) loops() int {
+	if l.count <= 0 {
+		return -1
+	}
+	return l.count
+}
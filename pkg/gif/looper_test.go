@@ -40,3 +40,15 @@ func TestCountLoop(t *testing.T) {
 		t.Fatalf(`err = %v, want %v`, err, ErrAnimationComplete)
 	}
 }
+
+// TestCountLoopLoops checks that the remaining loops of a countLoop would
+// decrease until the animation plays only once more.
+func TestCountLoopLoops(t *testing.T) {
+	l := &countLoop{2}
+	for _, want := range []int{2, 1, -1} {
+		if actual := l.loops(); actual != want {
+			t.Errorf(`loops = %d, want %d`, actual, want)
+		}
+		l.nextLoop()
+	}
+}
@@ -0,0 +1,79 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// Filter is the filter that images are resampled with when they are scaled.
+type Filter int
+
+const (
+	// Linear interpolates linearly between pixels. It is the zero value, so
+	// that options without a filter resample smoothly.
+	Linear Filter = iota
+	// Nearest uses the nearest pixel, which keeps pixel art crisp.
+	Nearest
+	// Box averages the pixels under each cell.
+	Box
+	// CatmullRom is a sharp cubic filter.
+	CatmullRom
+	// Lanczos is a sharp filter that is good for downscaling photos, but is
+	// the slowest.
+	Lanczos
+)
+
+// Filters are the names and resampling filters of filters, in the order that
+// they are cycled through.
+var filters = [...]struct {
+	name     string
+	resample imaging.ResampleFilter
+}{
+	Linear:     {"linear", imaging.Linear},
+	Nearest:    {"nearest", imaging.NearestNeighbor},
+	Box:        {"box", imaging.Box},
+	CatmullRom: {"catmull-rom", imaging.CatmullRom},
+	Lanczos:    {"lanczos", imaging.Lanczos},
+}
+
+// ParseFilter parses the name of a filter, like "nearest" or "lanczos".
+func ParseFilter(s string) (Filter, error) {
+	names := make([]string, len(filters))
+	for i, f := range filters {
+		if s == f.name {
+			return Filter(i), nil
+		}
+		names[i] = f.name
+	}
+	return Linear, fmt.Errorf("Unknown filter %q, want %s", s, strings.Join(names, ", "))
+}
+
+// String gets the name of the filter.
+func (f Filter) String() string {
+	if !f.valid() {
+		return fmt.Sprintf("Filter(%d)", int(f))
+	}
+	return filters[f].name
+}
+
+// Valid checks if the filter is one of the filters.
+func (f Filter) valid() bool {
+	return f >= 0 && int(f) < len(filters)
+}
+
+// Next gets the filter after this one, going back to the first filter after
+// the last.
+func (f Filter) Next() Filter {
+	return (f + 1) % Filter(len(filters))
+}
+
+// Resample gets the filter that imaging resamples with. Filters that aren't
+// one of the filters resample linearly.
+func (f Filter) Resample() imaging.ResampleFilter {
+	if !f.valid() {
+		return filters[Linear].resample
+	}
+	return filters[f].resample
+}
@@ -0,0 +1,49 @@
+package render
+
+import "testing"
+
+// TestParseFilter checks that filters would be parsed by name, and that their
+// names would be formatted the same way.
+func TestParseFilter(t *testing.T) {
+	for s, want := range map[string]Filter{
+		"nearest":     Nearest,
+		"box":         Box,
+		"linear":      Linear,
+		"catmull-rom": CatmullRom,
+		"lanczos":     Lanczos,
+	} {
+		actual, err := ParseFilter(s)
+		if err != nil {
+			t.Fatalf(`err = %v, want nil`, err)
+		}
+		if actual != want {
+			t.Errorf(`ParseFilter(%q) = %v, want %v`, s, actual, want)
+		}
+		if actual.String() != s {
+			t.Errorf(`String() = %q, want %q`, actual.String(), s)
+		}
+	}
+	if _, err := ParseFilter("bicubic"); err == nil {
+		t.Errorf(`ParseFilter("bicubic") err = nil`)
+	}
+}
+
+// TestFilterZero checks that options without a filter would resample
+// linearly.
+func TestFilterZero(t *testing.T) {
+	if actual := (Options{}).Filter; actual != Linear {
+		t.Errorf(`Filter = %v, want %v`, actual, Linear)
+	}
+}
+
+// TestFilterNext checks that cycling would go through every filter and back
+// to the first.
+func TestFilterNext(t *testing.T) {
+	f := Nearest
+	for i := 0; i < len(filters); i++ {
+		f = f.Next()
+	}
+	if f != Nearest {
+		t.Errorf(`after cycling = %v, want %v`, f, Nearest)
+	}
+}
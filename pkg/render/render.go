@@ -0,0 +1,154 @@
+// Package render draws images as colored text with the same engine as the
+// termage viewer, so that other programs can show image previews.
+//
+// Images are scaled to fit a number of cells, and are never enlarged. Because
+// character cells are taller than they are wide, images are stretched
+// horizontally by PixelHeight to keep their proportions.
+package render
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"math"
+
+	"github.com/disintegration/imaging"
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/spenserblack/termage/internal/conversion"
+	"github.com/spenserblack/termage/internal/draw"
+)
+
+// PixelHeight is how many times taller than wide a character cell is.
+const PixelHeight = 2.15
+
+// Mode decides which characters pixels are drawn with.
+type Mode = conversion.Mode
+
+const (
+	// AlphaMode uses shading characters for the alpha level of each pixel.
+	AlphaMode = conversion.AlphaMode
+	// BlockMode uses full blocks, blending transparent pixels with the
+	// background.
+	BlockMode = conversion.BlockMode
+	// ASCIIMode uses ASCII characters for the brightness of each pixel.
+	ASCIIMode = conversion.ASCIIMode
+)
+
+// Colors limits the colors that images are drawn with.
+type Colors = draw.Palette
+
+const (
+	// TrueColor draws images with 24-bit colors.
+	TrueColor = draw.TrueColor
+	// Colors256 draws images with the 256 standard terminal colors.
+	Colors256 = draw.Palette256
+	// Colors16 draws images with the 16 basic terminal colors.
+	Colors16 = draw.Palette16
+	// NoColor draws images with the terminal's foreground color.
+	NoColor = draw.NoColor
+)
+
+// ParseMode parses the name of a mode: alpha, block or ascii.
+func ParseMode(name string) (Mode, error) {
+	return conversion.ParseMode(name)
+}
+
+// ParseColors parses the name of colors: truecolor, 256, 16 or none.
+func ParseColors(name string) (Colors, error) {
+	return draw.ParsePalette(name)
+}
+
+// Options configure how images are rendered.
+type Options struct {
+	// Width and Height are the largest size of a rendered image in cells.
+	// 0 doesn't limit the size, and renders each pixel one cell tall.
+	Width, Height int
+	Mode          Mode
+	Colors        Colors
+	// Filter is the filter that images are resampled with. The zero value
+	// is Linear.
+	Filter Filter
+	// Background is the background color of images. Nil is the terminal's
+	// background color.
+	Background color.Color
+}
+
+// Renderer renders images as colored text.
+type Renderer interface {
+	// Size gets the size in cells that an image of some bounds is rendered
+	// at.
+	Size(bounds image.Rectangle) image.Point
+	// Render writes an image as lines of text colored with ANSI escape codes.
+	Render(w io.Writer, m image.Image) error
+	// Draw draws an image centered in a region of a screen, clearing the
+	// rest of the region. The image is scaled to fit the region as well as
+	// the size of the options. The caller shows the screen.
+	Draw(s tcell.Screen, region image.Rectangle, m image.Image)
+}
+
+// Renderer renders with options.
+type renderer struct {
+	options   Options
+	converter conversion.Converter
+	style     draw.Style
+}
+
+// New creates a renderer.
+func New(options Options) Renderer {
+	r := &renderer{
+		options:   options,
+		converter: conversion.Converter{Mode: options.Mode},
+		style:     draw.Style{Palette: options.Colors},
+	}
+	if options.Background != nil {
+		r.converter.Background = options.Background
+		r.style.Background = tcell.FromImageColor(options.Background)
+	}
+	return r
+}
+
+// Size gets the size in cells that an image of some bounds is rendered at.
+func (r *renderer) Size(bounds image.Rectangle) image.Point {
+	return fit(bounds, image.Point{r.options.Width, r.options.Height})
+}
+
+// Render writes an image as lines of text colored with ANSI escape codes.
+func (r *renderer) Render(w io.Writer, m image.Image) error {
+	return draw.WriteANSI(w, r.convert(m, r.Size(m.Bounds())), r.style)
+}
+
+// Draw draws an image centered in a region of a screen. The screen isn't
+// shown, so that the caller can draw the rest of it first.
+func (r *renderer) Draw(s tcell.Screen, region image.Rectangle, m image.Image) {
+	size := fit(m.Bounds(), region.Size())
+	if limit := r.Size(m.Bounds()); limit.X < size.X || limit.Y < size.Y {
+		size = limit
+	}
+	draw.StyledImageIn(s, r.convert(m, size), region, r.style)
+}
+
+// Convert scales an image to a size in cells and converts it.
+func (r *renderer) convert(m image.Image, size image.Point) conversion.RGBRunes {
+	scaled := imaging.Resize(m, size.X, size.Y, r.options.Filter.Resample())
+	return r.converter.FromImage(scaled)
+}
+
+// Fit gets the size in cells of an image that fits in a number of cells
+// without being enlarged. A limit of 0 doesn't limit that side. Images are
+// always at least 1 cell wide and tall.
+func fit(bounds image.Rectangle, limit image.Point) image.Point {
+	width := float64(bounds.Dx()) * PixelHeight
+	height := float64(bounds.Dy())
+	scale := 1.0
+	if limit.X > 0 {
+		scale = math.Min(scale, float64(limit.X)/width)
+	}
+	if limit.Y > 0 {
+		scale = math.Min(scale, float64(limit.Y)/height)
+	}
+	return image.Point{
+		int(math.Max(math.Round(width*scale), 1)),
+		int(math.Max(math.Round(height*scale), 1)),
+	}
+}
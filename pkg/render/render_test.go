@@ -0,0 +1,113 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// TestSize checks that images would fit the size of the options without
+// being enlarged, and that a size of 0 wouldn't limit that side.
+func TestSize(t *testing.T) {
+	bounds := image.Rect(0, 0, 100, 50)
+	for _, tt := range []struct {
+		width, height int
+		want          image.Point
+	}{
+		{0, 0, image.Point{215, 50}},
+		{43, 0, image.Point{43, 10}},
+		{0, 10, image.Point{43, 10}},
+		{1000, 1000, image.Point{215, 50}},
+	} {
+		r := New(Options{Width: tt.width, Height: tt.height})
+		if actual := r.Size(bounds); actual != tt.want {
+			t.Errorf(`%dx%d: Size = %v, want %v`, tt.width, tt.height, actual, tt.want)
+		}
+	}
+}
+
+// TestRender checks that an image would be written as one line of colored
+// text for each row of cells.
+func TestRender(t *testing.T) {
+	m := image.NewUniform(color.RGBA{0, 0xFF, 0, 0xFF})
+	r := New(Options{Width: 8, Height: 2, Mode: BlockMode, Colors: Colors256})
+
+	var out bytes.Buffer
+	if err := r.Render(&out, &croppedUniform{m, image.Rect(0, 0, 4, 2)}); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf(`lines = %q, want 2 lines`, lines)
+	}
+	// NOTE Green is one of the 16 basic colors, which have their own codes
+	if want := "\x1b[0m\x1b[92m" + strings.Repeat("█", 8) + "\x1b[0m"; lines[0] != want {
+		t.Errorf(`line = %q, want %q`, lines[0], want)
+	}
+}
+
+// TestRenderUnknownFilter checks that a filter that isn't one of the filters
+// would resample linearly instead of failing.
+func TestRenderUnknownFilter(t *testing.T) {
+	m := &croppedUniform{image.NewUniform(color.White), image.Rect(0, 0, 40, 20)}
+	r := New(Options{Width: 8, Height: 2, Filter: 9})
+
+	var out bytes.Buffer
+	if err := r.Render(&out, m); err != nil {
+		t.Fatalf(`err = %v, want nil`, err)
+	}
+	if actual := Filter(9).String(); actual != "Filter(9)" {
+		t.Errorf(`String() = %q, want "Filter(9)"`, actual)
+	}
+}
+
+// TestDraw checks that an image would be scaled to fit a region of a screen
+// and centered in it, and that the screen would be left for the caller to
+// show.
+func TestDraw(t *testing.T) {
+	s := tcell.NewSimulationScreen("")
+	if err := s.Init(); err != nil {
+		panic(err)
+	}
+	defer s.Fini()
+	s.SetSize(20, 10)
+	m := &croppedUniform{image.NewUniform(color.White), image.Rect(0, 0, 40, 20)}
+
+	New(Options{Mode: BlockMode}).Draw(s, image.Rect(10, 0, 20, 10), m)
+	if cells, width, _ := s.GetContents(); len(cells[4*width+10].Runes) != 0 {
+		t.Errorf(`screen was shown by Draw`)
+	}
+	s.Show()
+
+	cells, width, _ := s.GetContents()
+	cell := func(x, y int) rune {
+		return cells[y*width+x].Runes[0]
+	}
+	// NOTE The 40x20 image fits the 10x10 region at 10x2 cells
+	for x := 10; x < 20; x++ {
+		if actual := cell(x, 4); actual != '█' {
+			t.Errorf(`rune @ %d, 4 = %q, want '█'`, x, actual)
+		}
+	}
+	if actual := cell(15, 3); actual != ' ' {
+		t.Errorf(`rune above image = %q, want ' '`, actual)
+	}
+	if actual := cell(5, 4); actual != ' ' {
+		t.Errorf(`rune outside of region = %q, want ' '`, actual)
+	}
+}
+
+// CroppedUniform is a uniform image with bounds.
+type croppedUniform struct {
+	*image.Uniform
+	bounds image.Rectangle
+}
+
+func (m *croppedUniform) Bounds() image.Rectangle {
+	return m.bounds
+}
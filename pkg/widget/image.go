@@ -0,0 +1,404 @@
+// Package widget provides an image pane for tcell applications, which zooms,
+// scrolls and draws images with the same engine as the termage viewer.
+//
+// Image is a widget of tcell's views package. Applications that don't use
+// views, like tview applications, can draw it into a region of a screen with
+// DrawIn.
+package widget
+
+import (
+	"image"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gdamore/tcell/v2/views"
+
+	"github.com/spenserblack/termage/internal/conversion"
+	"github.com/spenserblack/termage/internal/draw"
+	"github.com/spenserblack/termage/internal/keys"
+	"github.com/spenserblack/termage/internal/view"
+	"github.com/spenserblack/termage/pkg/render"
+)
+
+// Zoom is a zoom level as a percentage. At 100%, each pixel of an image is
+// drawn one cell tall.
+type Zoom = view.Zoom
+
+// ZoomMode decides the zoom of images when they are set and when the widget
+// is resized.
+type ZoomMode = view.ZoomMode
+
+const (
+	// ZoomFit fits the whole image in the widget, without enlarging it.
+	ZoomFit = view.ZoomFit
+	// ZoomFitWidth fits the width of the image to the widget.
+	ZoomFitWidth = view.ZoomFitWidth
+	// ZoomFitHeight fits the height of the image to the widget.
+	ZoomFitHeight = view.ZoomFitHeight
+	// ZoomActualSize draws each pixel of the image one cell tall.
+	ZoomActualSize = view.ZoomActualSize
+)
+
+// ScrollStep is the percentage of the image that fast scrolling moves.
+const scrollStep = 10
+
+// Image is a widget that shows an image. The image is zoomed to the zoom
+// mode, and only the visible part of it is rendered.
+//
+// Images are zoomed and scrolled with the default keys of the viewer, zoomed
+// at the cursor with the mouse wheel, and dragged with the left mouse button.
+type Image struct {
+	views.WidgetWatchers
+	options   render.Options
+	converter conversion.Converter
+	style     draw.Style
+	bindings  keys.Bindings
+	view      views.View
+	// Area is the size of the view when the widget was last resized.
+	area     image.Point
+	image    image.Image
+	mode     ZoomMode
+	zoom     Zoom
+	resample render.Filter
+	// Offset is the offset of the image from the center of the view.
+	offset image.Point
+	// Rendered is nil when the image needs to be rendered again.
+	rendered *view.Viewport
+	// Buttons are the mouse buttons that were held at the last mouse event.
+	buttons tcell.ButtonMask
+	// Dragging is true while the image is being dragged from the last
+	// position.
+	dragging     bool
+	lastPosition image.Point
+	// Port is the view that DrawIn draws to.
+	port       *views.ViewPort
+	portScreen tcell.Screen
+	portRegion image.Rectangle
+}
+
+// NewImage creates an image widget. The width and height of the options are
+// the size the widget asks for, and 0 asks for the size of the image.
+func NewImage(options render.Options) *Image {
+	w := &Image{
+		options:   options,
+		converter: conversion.Converter{Mode: options.Mode},
+		style:     draw.Style{Palette: options.Colors},
+		bindings:  keys.DefaultBindings(),
+		resample:  options.Filter,
+		zoom:      100,
+	}
+	if options.Background != nil {
+		w.converter.Background = options.Background
+		w.style.Background = tcell.FromImageColor(options.Background)
+	}
+	return w
+}
+
+// SetImage shows an image, zoomed to the zoom mode and centered. Nil clears
+// the widget.
+func (w *Image) SetImage(m image.Image) {
+	w.image = m
+	w.offset = image.Point{}
+	if m != nil {
+		w.zoom = w.modeZoom()
+	}
+	w.changed()
+}
+
+// Image gets the image that is shown.
+func (w *Image) Image() image.Image {
+	return w.image
+}
+
+// SetZoomMode zooms the image to a zoom mode, which is kept when the image
+// changes or the widget is resized.
+func (w *Image) SetZoomMode(mode ZoomMode) {
+	w.mode = mode
+	if w.image == nil {
+		return
+	}
+	w.rezoom(w.modeZoom(), w.area.Div(2))
+}
+
+// SetZoom zooms the image around the center of the widget.
+func (w *Image) SetZoom(zoom Zoom) {
+	if zoom < view.MinZoom {
+		zoom = view.MinZoom
+	} else if zoom > view.MaxZoom {
+		zoom = view.MaxZoom
+	}
+	w.rezoom(zoom, w.area.Div(2))
+}
+
+// Zoom gets the zoom of the image.
+func (w *Image) Zoom() Zoom {
+	return w.zoom
+}
+
+// ZoomIn zooms into the center of the widget by a step.
+func (w *Image) ZoomIn() {
+	w.zoomAt(true, w.area.Div(2))
+}
+
+// ZoomOut zooms out of the center of the widget by a step.
+func (w *Image) ZoomOut() {
+	w.zoomAt(false, w.area.Div(2))
+}
+
+// SetFilter sets the filter that the image is resampled with when it is
+// zoomed.
+func (w *Image) SetFilter(filter render.Filter) {
+	w.resample = filter
+	w.changed()
+}
+
+// Filter gets the filter that the image is resampled with.
+func (w *Image) Filter() render.Filter {
+	return w.resample
+}
+
+// Scroll moves the image by a number of cells. The image never scrolls past
+// its edges.
+func (w *Image) Scroll(dx, dy int) {
+	if w.image == nil {
+		return
+	}
+	x, y := view.ClampOffset(w.offset.Add(image.Point{dx, dy}), w.size(), w.area)
+	w.offset = image.Point{x, y}
+	w.PostEventWidgetContent(w)
+}
+
+// Offset gets the offset of the center of the image from the center of the
+// widget.
+func (w *Image) Offset() image.Point {
+	return w.offset
+}
+
+// Draw draws the image to the view, rendering the visible part of it if it
+// hasn't been rendered.
+func (w *Image) Draw() {
+	if w.view == nil {
+		return
+	}
+	if w.image == nil {
+		w.view.Clear()
+		return
+	}
+	size := w.size()
+	if w.rendered == nil || !w.rendered.Covers(w.offset, size, w.area) {
+		v := view.View{
+			Zoom:       w.zoom,
+			Resampling: w.resample,
+			Rect:       view.RenderRect(w.offset, size, w.area),
+		}
+		rendered := view.RenderViewport(w.image, v, w.converter)
+		w.rendered = &rendered
+	}
+	rgbRunes := w.rendered.RGBRunes
+	// NOTE Centers the image in the image area, like view.VisibleRect
+	//      expects
+	center := w.rendered.DrawOffset(w.offset, size)
+	topLeft := w.area.Div(2).Sub(image.Point{rgbRunes.Width(), rgbRunes.Height()}.Div(2)).Add(center)
+	draw.ImageAt(w.view, rgbRunes, topLeft, w.style)
+}
+
+// Resize updates the widget to the size of its view. The zoom follows the
+// zoom mode, unless it was changed.
+func (w *Image) Resize() {
+	area := image.Point{}
+	if w.view != nil {
+		area.X, area.Y = w.view.Size()
+	}
+	if w.image == nil {
+		w.area = area
+		w.changed()
+		return
+	}
+	wasModeZoom := w.zoom == w.modeZoom()
+	w.area = area
+	if wasModeZoom {
+		w.zoom = w.modeZoom()
+	}
+	x, y := view.ClampOffset(w.offset, w.size(), w.area)
+	w.offset = image.Point{x, y}
+	w.changed()
+}
+
+// HandleEvent zooms and scrolls the image for key and mouse events. It
+// returns true if the event was used.
+func (w *Image) HandleEvent(ev tcell.Event) bool {
+	if w.image == nil {
+		return false
+	}
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		return w.handleKey(ev)
+	case *tcell.EventMouse:
+		return w.handleMouse(ev)
+	}
+	return false
+}
+
+// HandleKey zooms and scrolls the image for the keys bound to zoom and scroll
+// actions.
+func (w *Image) handleKey(ev *tcell.EventKey) bool {
+	action, ok := w.bindings.Action(ev)
+	if !ok {
+		return false
+	}
+	size := w.size()
+	fastX, fastY := size.X*scrollStep/100, size.Y*scrollStep/100
+	switch action {
+	case keys.ZoomIn:
+		w.ZoomIn()
+	case keys.ZoomOut:
+		w.ZoomOut()
+	case keys.Fit:
+		w.SetZoomMode(ZoomFit)
+	case keys.FitWidth:
+		w.SetZoomMode(ZoomFitWidth)
+	case keys.FitHeight:
+		w.SetZoomMode(ZoomFitHeight)
+	case keys.ActualSize:
+		w.SetZoomMode(ZoomActualSize)
+	case keys.NextFilter:
+		w.SetFilter(w.resample.Next())
+	case keys.ScrollLeft:
+		w.Scroll(-1, 0)
+	case keys.ScrollLeftFast:
+		w.Scroll(-fastX, 0)
+	case keys.ScrollDown:
+		w.Scroll(0, 1)
+	case keys.ScrollDownFast:
+		w.Scroll(0, fastY)
+	case keys.ScrollUp:
+		w.Scroll(0, -1)
+	case keys.ScrollUpFast:
+		w.Scroll(0, -fastY)
+	case keys.ScrollRight:
+		w.Scroll(1, 0)
+	case keys.ScrollRightFast:
+		w.Scroll(fastX, 0)
+	default:
+		return false
+	}
+	return true
+}
+
+// HandleMouse zooms the image at the cursor with the mouse wheel, and drags
+// it with the left button. Events outside of the widget are only used to
+// keep dragging.
+func (w *Image) handleMouse(ev *tcell.EventMouse) bool {
+	x, y := ev.Position()
+	position := image.Point{x, y}.Sub(w.origin())
+	pressed := ev.Buttons() &^ w.buttons
+	w.buttons = ev.Buttons()
+	inside := position.In(image.Rectangle{Max: w.area})
+	switch {
+	case w.buttons&tcell.Button1 != 0 && w.dragging:
+		d := position.Sub(w.lastPosition)
+		w.Scroll(d.X, d.Y)
+		w.lastPosition = position
+		return true
+	case w.buttons&tcell.Button1 == 0 && w.dragging:
+		w.dragging = false
+		return true
+	case !inside:
+		return false
+	case w.buttons&tcell.WheelUp != 0:
+		w.zoomAt(true, position)
+	case w.buttons&tcell.WheelDown != 0:
+		w.zoomAt(false, position)
+	case pressed&tcell.Button1 != 0:
+		w.dragging = true
+		w.lastPosition = position
+	default:
+		return false
+	}
+	return true
+}
+
+// SetView sets the view that the widget draws to, and resizes the widget to
+// it.
+func (w *Image) SetView(v views.View) {
+	w.view = v
+	w.Resize()
+}
+
+// Size gets the size that the widget asks for: the width and height of the
+// options, or the size that the image fits in at those limits.
+func (w *Image) Size() (int, int) {
+	if w.image == nil {
+		return w.options.Width, w.options.Height
+	}
+	size := render.New(w.options).Size(w.image.Bounds())
+	return size.X, size.Y
+}
+
+// DrawIn draws the widget to a region of a screen, resizing the widget when
+// the region changes. This draws the widget in applications that don't use
+// views, like in the Draw method of a tview primitive.
+func (w *Image) DrawIn(s tcell.Screen, region image.Rectangle) {
+	x, y, width, height := region.Min.X, region.Min.Y, region.Dx(), region.Dy()
+	switch {
+	case w.port == nil || w.portScreen != s:
+		w.port = views.NewViewPort(s, x, y, width, height)
+		w.portScreen, w.portRegion = s, region
+		w.SetView(w.port)
+	case w.portRegion != region:
+		w.port.Resize(x, y, width, height)
+		w.portRegion = region
+		w.Resize()
+	}
+	w.Draw()
+}
+
+// ModeZoom gets the zoom of the zoom mode for the image in the widget.
+func (w *Image) modeZoom() Zoom {
+	return w.mode.Zoom(w.area, w.image.Bounds(), w.zoom)
+}
+
+// Size gets the size of the zoomed image in cells.
+func (w *Image) size() image.Point {
+	return w.zoom.Size(w.image.Bounds())
+}
+
+// ZoomAt zooms in or out by a step, keeping the point of the image under the
+// anchor, a point in the widget, in place.
+func (w *Image) zoomAt(in bool, anchor image.Point) {
+	if w.image == nil {
+		return
+	}
+	w.rezoom(w.zoom.Step(view.FitZoom(w.area, w.image.Bounds()), in), anchor)
+}
+
+// Rezoom zooms the image, keeping the point of the image under the anchor in
+// place.
+func (w *Image) rezoom(zoom Zoom, anchor image.Point) {
+	if w.image == nil {
+		return
+	}
+	x, y := view.AnchorZoom(anchor, w.offset, w.area, w.image.Bounds(), w.zoom, zoom)
+	w.offset = image.Point{x, y}
+	w.zoom = zoom
+	w.changed()
+}
+
+// Changed marks the image to be rendered again, and tells watchers that the
+// content of the widget changed.
+func (w *Image) changed() {
+	w.rendered = nil
+	w.PostEventWidgetContent(w)
+}
+
+// Origin gets the position of the top-left corner of the view on the screen,
+// which mouse events are relative to. Views that aren't view ports are
+// assumed to cover the screen from its top-left corner.
+func (w *Image) origin() image.Point {
+	if port, ok := w.view.(interface {
+		GetPhysical() (int, int, int, int)
+	}); ok {
+		x, y, _, _ := port.GetPhysical()
+		return image.Point{x, y}
+	}
+	return image.Point{}
+}
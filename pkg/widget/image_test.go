@@ -0,0 +1,148 @@
+package widget
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/spenserblack/termage/pkg/render"
+)
+
+// TestDrawIn checks that an image would be fitted to a region of a screen,
+// without drawing outside of the region.
+func TestDrawIn(t *testing.T) {
+	s := newScreen(20, 10)
+	defer s.Fini()
+	s.SetContent(5, 4, 'x', nil, tcell.StyleDefault)
+	w := NewImage(render.Options{Mode: render.BlockMode})
+	w.SetImage(uniform(40, 20))
+
+	w.DrawIn(s, image.Rect(10, 0, 20, 10))
+
+	// NOTE The 40x20 image fits the 10x10 region at 10x2 cells
+	for x := 10; x < 20; x++ {
+		if actual := cellAt(s, x, 4); actual != '█' {
+			t.Errorf(`rune @ %d, 4 = %q, want '█'`, x, actual)
+		}
+	}
+	if actual := cellAt(s, 15, 3); actual != ' ' {
+		t.Errorf(`rune above image = %q, want ' '`, actual)
+	}
+	if actual := cellAt(s, 5, 4); actual != 'x' {
+		t.Errorf(`rune outside of region = %q, want 'x'`, actual)
+	}
+}
+
+// TestResize checks that the zoom would follow the zoom mode when the region
+// changes, unless the zoom was changed.
+func TestResize(t *testing.T) {
+	s := newScreen(100, 20)
+	defer s.Fini()
+	w := NewImage(render.Options{Mode: render.BlockMode})
+	w.SetImage(uniform(40, 20))
+	w.SetZoomMode(ZoomFitWidth)
+
+	w.DrawIn(s, image.Rect(0, 0, 43, 20))
+	fitted := w.Zoom()
+	w.DrawIn(s, image.Rect(0, 0, 86, 20))
+	if resized := w.Zoom(); resized <= fitted {
+		t.Errorf(`zoom after widening = %v, want more than %v`, resized, fitted)
+	}
+
+	w.SetZoom(50)
+	w.DrawIn(s, image.Rect(0, 0, 43, 20))
+	if actual := w.Zoom(); actual != 50 {
+		t.Errorf(`changed zoom after resizing = %v, want 50%%`, actual)
+	}
+}
+
+// TestHandleKey checks that zoom and scroll keys would be used, and that
+// other keys would be left to the application.
+func TestHandleKey(t *testing.T) {
+	s := newScreen(10, 10)
+	defer s.Fini()
+	w := NewImage(render.Options{Mode: render.BlockMode})
+	w.SetImage(uniform(40, 20))
+	w.DrawIn(s, image.Rect(0, 0, 10, 10))
+	before := w.Zoom()
+
+	if !w.HandleEvent(tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone)) {
+		t.Fatalf(`zoom in key wasn't used`)
+	}
+	if after := w.Zoom(); after <= before {
+		t.Errorf(`zoom = %v, want more than %v`, after, before)
+	}
+	if !w.HandleEvent(tcell.NewEventKey(tcell.KeyRune, 'l', tcell.ModNone)) {
+		t.Fatalf(`scroll key wasn't used`)
+	}
+	if actual := w.Offset(); actual != (image.Point{1, 0}) {
+		t.Errorf(`offset = %v, want (1,0)`, actual)
+	}
+	if w.HandleEvent(tcell.NewEventKey(tcell.KeyRune, 'n', tcell.ModNone)) {
+		t.Errorf(`next image key was used`)
+	}
+}
+
+// TestHandleMouse checks that the wheel would zoom at the cursor inside of the
+// widget, and that dragging would scroll the image.
+func TestHandleMouse(t *testing.T) {
+	s := newScreen(40, 20)
+	defer s.Fini()
+	w := NewImage(render.Options{Mode: render.BlockMode})
+	w.SetImage(uniform(40, 20))
+	w.SetZoomMode(ZoomActualSize)
+	w.DrawIn(s, image.Rect(20, 0, 40, 20))
+
+	if w.HandleEvent(tcell.NewEventMouse(5, 5, tcell.WheelUp, tcell.ModNone)) {
+		t.Errorf(`wheel outside of widget was used`)
+	}
+	if !w.HandleEvent(tcell.NewEventMouse(25, 5, tcell.WheelUp, tcell.ModNone)) {
+		t.Fatalf(`wheel inside of widget wasn't used`)
+	}
+	if actual := w.Zoom(); actual <= 100 {
+		t.Errorf(`zoom = %v, want more than 100%%`, actual)
+	}
+	// NOTE The left side of the widget was zoomed into, so the image moved
+	//      right
+	if actual := w.Offset(); actual.X <= 0 {
+		t.Errorf(`offset after zooming at the left = %v, want a positive x`, actual)
+	}
+
+	before := w.Offset()
+	w.HandleEvent(tcell.NewEventMouse(30, 10, tcell.Button1, tcell.ModNone))
+	w.HandleEvent(tcell.NewEventMouse(28, 9, tcell.Button1, tcell.ModNone))
+	w.HandleEvent(tcell.NewEventMouse(28, 9, tcell.ButtonNone, tcell.ModNone))
+	if actual, want := w.Offset(), before.Add(image.Point{-2, -1}); actual != want {
+		t.Errorf(`offset after dragging = %v, want %v`, actual, want)
+	}
+}
+
+// NewScreen creates a simulation screen of a size.
+func newScreen(width, height int) tcell.SimulationScreen {
+	s := tcell.NewSimulationScreen("")
+	if err := s.Init(); err != nil {
+		panic(err)
+	}
+	s.SetSize(width, height)
+	return s
+}
+
+// CellAt gets the rune of a cell of a simulation screen.
+func cellAt(s tcell.SimulationScreen, x, y int) rune {
+	s.Show()
+	cells, width, _ := s.GetContents()
+	return cells[y*width+x].Runes[0]
+}
+
+// Uniform creates a white image of a size.
+func uniform(width, height int) image.Image {
+	m := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			m.Set(x, y, color.White)
+		}
+	}
+	return m
+}